@@ -1,6 +1,7 @@
 package clause
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -8,8 +9,10 @@ import (
 type Clause struct {
 	sql     map[Type]string        // 存储不同类型的 SQL 语句
 	sqlVars map[Type][]interface{} // 存储 SQL 语句对应的参数
+	err     error                  // 设置子句时发生的错误，在 Build 时返回
 }
 
+// Type 表示子句的类型，自定义类型通过 Register 注册
 type Type int
 
 // 定义 SQL 语句的类型
@@ -23,6 +26,12 @@ const (
 	UPDATE
 	DELETE
 	COUNT
+	GROUPBY
+	HAVING
+	COMPOUND
+	WITH
+	LOCK
+	RETURNING
 )
 
 // Set 方法用于设置某种类型的 SQL 语句及其对应的参数
@@ -30,12 +39,21 @@ const (
 // 参数:
 // name: SQL 语句的类型
 // vars: SQL 语句对应的参数
+//
+// name 没有注册时不设置子句，并记录错误，该错误在 Build 时返回
 func (c *Clause) Set(name Type, vars ...interface{}) {
 	if c.sql == nil {
 		c.sql = make(map[Type]string)
 		c.sqlVars = make(map[Type][]interface{})
 	}
-	sql, vars := generators[name](vars...)
+	mu.RLock()
+	generate, ok := generators[name]
+	mu.RUnlock()
+	if !ok {
+		c.err = fmt.Errorf("clause type %v is not registered", name)
+		return
+	}
+	sql, vars := generate(vars...)
 	c.sql[name] = sql
 	c.sqlVars[name] = vars
 }
@@ -48,7 +66,11 @@ func (c *Clause) Set(name Type, vars ...interface{}) {
 // 返回值:
 // string: 构建的 SQL 语句
 // []interface{}: SQL 语句对应的参数
-func (c *Clause) Build(orders ...Type) (string, []interface{}) {
+// error: 设置子句时发生的错误，例如子句类型没有注册
+func (c *Clause) Build(orders ...Type) (string, []interface{}, error) {
+	if c.err != nil {
+		return "", nil, c.err
+	}
 	var sqls []string
 	var vars []interface{}
 	for _, order := range orders {
@@ -57,5 +79,5 @@ func (c *Clause) Build(orders ...Type) (string, []interface{}) {
 			vars = append(vars, c.sqlVars[order]...)
 		}
 	}
-	return strings.Join(sqls, " "), vars
+	return strings.Join(sqls, " "), vars, nil
 }
@@ -1,7 +1,10 @@
 package clause_test
 
 import (
+	"fmt"
 	"geeorm/clause"
+	"geeorm/dialect"
+	"geeorm/schema"
 	"reflect"
 	"testing"
 )
@@ -12,7 +15,7 @@ func testSelect(t *testing.T) {
 	c.Set(clause.SELECT, "User", []string{"*"})
 	c.Set(clause.WHERE, "Name = ?", "Tom")
 	c.Set(clause.ORDERBY, "Age DESC")
-	sql, vars := c.Build(clause.SELECT, clause.WHERE, clause.ORDERBY, clause.LIMIT)
+	sql, vars, _ := c.Build(clause.SELECT, clause.WHERE, clause.ORDERBY, clause.LIMIT)
 	t.Log(sql, vars)
 	if sql != "SELECT * FROM User WHERE Name = ? ORDER BY Age DESC LIMIT ?" {
 		t.Fatal("failed to build SQL")
@@ -25,3 +28,206 @@ func testSelect(t *testing.T) {
 func TestClause_Build(t *testing.T) {
 	t.Run("SELECT", testSelect)
 }
+
+type User struct {
+	Name string `geeorm:"PRIMARY KEY"`
+	Age  int
+}
+
+func TestExpression_Build(t *testing.T) {
+	d, _ := dialect.GetDialect("sqlite3")
+	table := schema.Parse(&User{}, d)
+	expr := clause.Or(
+		clause.And(clause.Eq{"Name": "Tom", "Age": nil}, clause.Like{"Name": "T%"}),
+		clause.In{"Name": []string{"Sam", "Jack"}},
+		clause.Between{Column: "Age", Low: 18, High: 30},
+		clause.IsNull{"Name"},
+	)
+	sql, vars, err := expr.Build(table, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `(("Age" IS NULL AND "Name" = ?) AND ("Name" LIKE ?)) OR ("Name" IN (?, ?)) OR ("Age" BETWEEN ? AND ?) OR ("Name" IS NULL)`
+	if sql != want {
+		t.Fatal("failed to build expression", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{"Tom", "T%", "Sam", "Jack", 18, 30}) {
+		t.Fatal("failed to build expression vars", vars)
+	}
+	if _, _, err = (clause.Gt{"Agee": 18}).Build(table, d); err == nil {
+		t.Fatal("expect error on unknown column")
+	}
+	if sql, _, _ = (clause.In{"Name": []string{}}).Build(table, d); sql != "1 = 0" {
+		t.Fatal("failed to build empty IN", sql)
+	}
+}
+
+func TestExpandVars(t *testing.T) {
+	sql, vars := clause.ExpandVars("Name IN (?) AND Note <> '?' AND Age > ? AND Data = ?",
+		[]interface{}{[]string{"Tom", "Sam"}, 18, []byte("x")})
+	if sql != "Name IN (?, ?) AND Note <> '?' AND Age > ? AND Data = ?" {
+		t.Fatal("failed to expand sql", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{"Tom", "Sam", 18, []byte("x")}) {
+		t.Fatal("failed to expand vars", vars)
+	}
+	if sql, vars = clause.ExpandVars("Name IN (?)", []interface{}{[]string{}}); sql != "Name IN (SELECT NULL WHERE 1 = 0)" || len(vars) != 0 {
+		t.Fatal("failed to expand empty slice", sql, vars)
+	}
+	if sql, _ = clause.ExpandVars("Name NOT IN (?)", []interface{}{[]int{}}); sql != "Name NOT IN (SELECT NULL WHERE 1 = 0)" {
+		t.Fatal("failed to expand empty slice in NOT IN", sql)
+	}
+	uuid := [16]byte{1, 2}
+	if sql, vars = clause.ExpandVars("ID = ?", []interface{}{uuid}); sql != "ID = ?" || !reflect.DeepEqual(vars, []interface{}{uuid}) {
+		t.Fatal("array should be bound as a single parameter", sql, vars)
+	}
+	c := clause.Clause{}
+	c.Set(clause.HAVING, "count(*) IN (?)", []int{2, 3})
+	if sql, vars, _ = c.Build(clause.HAVING); sql != "HAVING count(*) IN (?, ?)" || !reflect.DeepEqual(vars, []interface{}{2, 3}) {
+		t.Fatal("failed to expand slice in HAVING", sql, vars)
+	}
+	sub := clause.Subquery{SQL: "SELECT AVG(Age) FROM User WHERE Name <> ?", Vars: []interface{}{"Tom"}}
+	sql, vars = clause.ExpandVars("Age > ? AND Age < ?", []interface{}{sub, 60})
+	if sql != "Age > (SELECT AVG(Age) FROM User WHERE Name <> ?) AND Age < ?" || !reflect.DeepEqual(vars, []interface{}{"Tom", 60}) {
+		t.Fatal("failed to expand subquery", sql, vars)
+	}
+}
+
+func TestNamed(t *testing.T) {
+	sql, vars, err := clause.Named("Age > @age AND (Name = :name OR Nick = @name) AND Note <> ':skip' AND Age::text <> ''",
+		map[string]interface{}{"age": 18, "name": "Tom"})
+	if err != nil || sql != "Age > ? AND (Name = ? OR Nick = ?) AND Note <> ':skip' AND Age::text <> ''" {
+		t.Fatal("failed to bind named parameters", err, sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{18, "Tom", "Tom"}) {
+		t.Fatal("failed to bind named vars", vars)
+	}
+	if _, _, err = clause.Named("Age > @min", map[string]interface{}{}); err == nil {
+		t.Fatal("expect error on missing parameter")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	sql := clause.Rebind("Name = ? AND Note <> '?' AND Age > ?", func(i int) string { return fmt.Sprintf("$%d", i) })
+	if sql != "Name = $1 AND Note <> '?' AND Age > $2" {
+		t.Fatal("failed to rebind placeholders", sql)
+	}
+}
+
+func TestClause_Compound(t *testing.T) {
+	c := clause.Clause{}
+	c.Set(clause.SELECT, "User", []string{"Name"})
+	c.Set(clause.WHERE, "Age > ?", 18)
+	c.Set(clause.COMPOUND, "UNION", clause.Subquery{SQL: "SELECT Name FROM Admin WHERE Level = ?", Vars: []interface{}{1}},
+		"EXCEPT", clause.Subquery{SQL: "SELECT Name FROM Banned"})
+	c.Set(clause.ORDERBY, "Name")
+	c.Set(clause.LIMIT, 10)
+	sql, vars, _ := c.Build(clause.SELECT, clause.WHERE, clause.COMPOUND, clause.ORDERBY, clause.LIMIT)
+	if sql != "SELECT Name FROM User WHERE Age > ? UNION SELECT Name FROM Admin WHERE Level = ? EXCEPT SELECT Name FROM Banned ORDER BY Name LIMIT ?" {
+		t.Fatal("failed to build compound SQL", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{18, 1, 10}) {
+		t.Fatal("failed to build compound SQL vars", vars)
+	}
+}
+
+func TestClause_With(t *testing.T) {
+	c := clause.Clause{}
+	c.Set(clause.WITH, true, "tree(ID)", clause.Subquery{SQL: "SELECT ? UNION ALL SELECT ID + 1 FROM tree WHERE ID < 5", Vars: []interface{}{1}})
+	c.Set(clause.SELECT, "tree", []string{"ID"})
+	sql, vars, _ := c.Build(clause.WITH, clause.SELECT)
+	if sql != "WITH RECURSIVE tree(ID) AS (SELECT ? UNION ALL SELECT ID + 1 FROM tree WHERE ID < 5) SELECT ID FROM tree" {
+		t.Fatal("failed to build CTE SQL", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{1}) {
+		t.Fatal("failed to build CTE SQL vars", vars)
+	}
+}
+
+func TestClause_Returning(t *testing.T) {
+	c := clause.Clause{}
+	c.Set(clause.DELETE, "User")
+	c.Set(clause.WHERE, "Age > ?", 60)
+	c.Set(clause.RETURNING, []string{"Name", "Age"})
+	sql, vars, _ := c.Build(clause.DELETE, clause.WHERE, clause.RETURNING)
+	if sql != "DELETE FROM User WHERE Age > ? RETURNING Name, Age" || !reflect.DeepEqual(vars, []interface{}{60}) {
+		t.Fatal("failed to build RETURNING SQL", sql, vars)
+	}
+}
+
+func TestClause_Update(t *testing.T) {
+	m := map[string]interface{}{"Name": "Tom", "Age": 18, "Email": "tom@example.com"}
+	for i := 0; i < 10; i++ {
+		c := clause.Clause{}
+		c.Set(clause.UPDATE, "User", m)
+		sql, vars, _ := c.Build(clause.UPDATE)
+		if sql != "UPDATE User SET Age = ?, Email = ?, Name = ?" || !reflect.DeepEqual(vars, []interface{}{18, "tom@example.com", "Tom"}) {
+			t.Fatal("failed to build UPDATE in key order", sql, vars)
+		}
+		c.Set(clause.UPDATE, "User", m, []string{"Name", "Age", "Email"})
+		sql, vars, _ = c.Build(clause.UPDATE)
+		if sql != "UPDATE User SET Name = ?, Age = ?, Email = ?" || !reflect.DeepEqual(vars, []interface{}{"Tom", 18, "tom@example.com"}) {
+			t.Fatal("failed to build UPDATE in given order", sql, vars)
+		}
+	}
+}
+
+func TestClause_UpdateExpr(t *testing.T) {
+	c := clause.Clause{}
+	c.Set(clause.UPDATE, "Post", map[string]interface{}{"Views": clause.Expr("Views + ?", 2), "Title": "Hi"}, []string{"Title", "Views"})
+	c.Set(clause.WHERE, "Views < ?", clause.Expr("Likes * ?", 10))
+	sql, vars, _ := c.Build(clause.UPDATE, clause.WHERE)
+	if sql != "UPDATE Post SET Title = ?, Views = Views + ? WHERE Views < Likes * ?" || !reflect.DeepEqual(vars, []interface{}{"Hi", 2, 10}) {
+		t.Fatal("failed to build UPDATE with expression", sql, vars)
+	}
+}
+
+// orderedDialect 将自定义子句放在 SELECT 之后，用于测试方言覆盖子句的构建顺序
+type orderedDialect struct {
+	dialect.Dialect
+	orders []clause.Type
+}
+
+func (d orderedDialect) ClauseOrders(stmt clause.Statement) []clause.Type {
+	if stmt == clause.SelectStatement {
+		return d.orders
+	}
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	useIndex := clause.Register("USE INDEX", func(values ...interface{}) (string, []interface{}) {
+		return fmt.Sprintf("INDEXED BY %v", values[0]), nil
+	})
+	if useIndex.String() != "USE INDEX" || clause.WHERE.String() != "WHERE" {
+		t.Fatal("failed to name clause types", useIndex, clause.WHERE)
+	}
+	d, _ := dialect.GetDialect("sqlite3")
+	c := clause.Clause{}
+	c.Set(clause.SELECT, "User", []string{"Name"})
+	c.Set(useIndex, "idx_age")
+	c.Set(clause.WHERE, "Age > ?", 18)
+	if sql, _, _ := c.BuildStatement(clause.SelectStatement, d); sql != "SELECT Name FROM User WHERE Age > ?" {
+		t.Fatal("unordered custom clause should not be built", sql)
+	}
+	od := orderedDialect{d, []clause.Type{clause.SELECT, useIndex, clause.WHERE}}
+	sql, vars, _ := c.BuildStatement(clause.SelectStatement, od)
+	if sql != "SELECT Name FROM User INDEXED BY idx_age WHERE Age > ?" || !reflect.DeepEqual(vars, []interface{}{18}) {
+		t.Fatal("failed to build custom clause", sql, vars)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expect panic on duplicate clause name")
+			}
+		}()
+		clause.Register("USE INDEX", nil)
+	}()
+	c.Set(clause.Type(1000), "x")
+	if _, _, err := c.Build(clause.SELECT); err == nil {
+		t.Fatal("expect error on unregistered clause type")
+	}
+	if orders := clause.Orders(clause.CountStatement, od); !reflect.DeepEqual(orders, clause.DefaultOrders(clause.CountStatement)) {
+		t.Fatal("dialect without orders should use default orders", orders)
+	}
+}
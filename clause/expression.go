@@ -0,0 +1,285 @@
+package clause
+
+import (
+	"fmt"
+	"geeorm/dialect"
+	"geeorm/schema"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Expression 是可以构建成 SQL 条件的表达式
+type Expression interface {
+	// Build 构建条件语句和对应的参数
+	//
+	// 参数:
+	// table: 用于校验列名的表结构，为 nil 时不校验
+	// d: 用于给列名加引号的数据库方言
+	//
+	// 返回值:
+	// string: 构建的条件语句
+	// []interface{}: 条件语句对应的参数
+	// error: 如果列名不存在于 table 中，返回错误信息
+	Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error)
+}
+
+// 类型断言，确保各表达式实现了 Expression 接口
+var (
+	_ Expression = Eq{}
+	_ Expression = Neq{}
+	_ Expression = Gt{}
+	_ Expression = Gte{}
+	_ Expression = Lt{}
+	_ Expression = Lte{}
+	_ Expression = Like{}
+	_ Expression = In{}
+	_ Expression = Between{}
+	_ Expression = IsNull{}
+	_ Expression = NotNull{}
+	_ Expression = and{}
+	_ Expression = or{}
+)
+
+// Eq 表示列等于给定值，值为 nil 时表示 IS NULL
+//
+// Eq{"Name": "Tom", "Age": 18} => "Age" = ? AND "Name" = ?
+type Eq map[string]interface{}
+
+// Neq 表示列不等于给定值，值为 nil 时表示 IS NOT NULL
+//
+// Neq{"Name": "Tom"} => "Name" <> ?
+type Neq map[string]interface{}
+
+// Gt 表示列大于给定值
+//
+// Gt{"Age": 18} => "Age" > ?
+type Gt map[string]interface{}
+
+// Gte 表示列大于等于给定值
+//
+// Gte{"Age": 18} => "Age" >= ?
+type Gte map[string]interface{}
+
+// Lt 表示列小于给定值
+//
+// Lt{"Age": 18} => "Age" < ?
+type Lt map[string]interface{}
+
+// Lte 表示列小于等于给定值
+//
+// Lte{"Age": 18} => "Age" <= ?
+type Lte map[string]interface{}
+
+// Like 表示列匹配给定的模式
+//
+// Like{"Name": "T%"} => "Name" LIKE ?
+type Like map[string]interface{}
+
+// In 表示列的值在给定的切片中，切片为空时条件恒为假
+//
+// In{"Name": []string{"Tom", "Sam"}} => "Name" IN (?, ?)
+type In map[string]interface{}
+
+// Between 表示列的值在 Low 和 High 之间（包含边界）
+//
+// Between{"Age", 18, 30} => "Age" BETWEEN ? AND ?
+type Between struct {
+	Column    string
+	Low, High interface{}
+}
+
+// IsNull 表示列的值为 NULL
+//
+// IsNull{"Email"} => "Email" IS NULL
+type IsNull []string
+
+// NotNull 表示列的值不为 NULL
+//
+// NotNull{"Email"} => "Email" IS NOT NULL
+type NotNull []string
+
+// Build 构建等于条件
+func (e Eq) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildCompare(e, "=", "IS NULL", table, d)
+}
+
+// Build 构建不等于条件
+func (e Neq) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildCompare(e, "<>", "IS NOT NULL", table, d)
+}
+
+// Build 构建大于条件
+func (e Gt) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildCompare(e, ">", "", table, d)
+}
+
+// Build 构建大于等于条件
+func (e Gte) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildCompare(e, ">=", "", table, d)
+}
+
+// Build 构建小于条件
+func (e Lt) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildCompare(e, "<", "", table, d)
+}
+
+// Build 构建小于等于条件
+func (e Lte) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildCompare(e, "<=", "", table, d)
+}
+
+// Build 构建模式匹配条件
+func (e Like) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildCompare(e, "LIKE", "", table, d)
+}
+
+// Build 构建 IN 条件
+func (e In) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	var conds []string
+	var vars []interface{}
+	for _, column := range sortedKeys(e) {
+		quoted, err := quoteColumn(column, table, d)
+		if err != nil {
+			return "", nil, err
+		}
+		v := reflect.ValueOf(e[column])
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return "", nil, fmt.Errorf("IN value of column %s must be a slice, got %T", column, e[column])
+		}
+		if v.Len() == 0 {
+			// 空集合中不存在任何值，条件恒为假
+			conds = append(conds, "1 = 0")
+			continue
+		}
+		conds = append(conds, fmt.Sprintf("%s IN (%s)", quoted, genBindVars(v.Len())))
+		for i := 0; i < v.Len(); i++ {
+			vars = append(vars, v.Index(i).Interface())
+		}
+	}
+	return strings.Join(conds, " AND "), vars, nil
+}
+
+// Build 构建 BETWEEN 条件
+func (e Between) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	quoted, err := quoteColumn(e.Column, table, d)
+	if err != nil {
+		return "", nil, err
+	}
+	return quoted + " BETWEEN ? AND ?", []interface{}{e.Low, e.High}, nil
+}
+
+// Build 构建 IS NULL 条件
+func (e IsNull) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildNull(e, "IS NULL", table, d)
+}
+
+// Build 构建 IS NOT NULL 条件
+func (e NotNull) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildNull(e, "IS NOT NULL", table, d)
+}
+
+// and 表示所有子表达式同时成立
+type and []Expression
+
+// or 表示任意一个子表达式成立
+type or []Expression
+
+// And 组合多个表达式，所有表达式同时成立时条件成立
+//
+// And(Eq{"Name": "Tom"}, Gt{"Age": 18}) => ("Name" = ?) AND ("Age" > ?)
+func And(exprs ...Expression) Expression {
+	return and(exprs)
+}
+
+// Or 组合多个表达式，任意一个表达式成立时条件成立
+//
+// Or(Eq{"Name": "Tom"}, Gt{"Age": 18}) => ("Name" = ?) OR ("Age" > ?)
+func Or(exprs ...Expression) Expression {
+	return or(exprs)
+}
+
+// Build 构建 AND 条件
+func (e and) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildJoin(e, "AND", table, d)
+}
+
+// Build 构建 OR 条件
+func (e or) Build(table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	return buildJoin(e, "OR", table, d)
+}
+
+// buildCompare 构建形如 "column op ?" 的比较条件，多个列之间用 AND 连接
+//
+// 值为 nil 且 nullOp 不为空时，构建 "column nullOp"，例如 "Email" IS NULL
+func buildCompare(m map[string]interface{}, op, nullOp string, table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	var conds []string
+	var vars []interface{}
+	for _, column := range sortedKeys(m) {
+		quoted, err := quoteColumn(column, table, d)
+		if err != nil {
+			return "", nil, err
+		}
+		if m[column] == nil && nullOp != "" {
+			conds = append(conds, quoted+" "+nullOp)
+			continue
+		}
+		conds = append(conds, fmt.Sprintf("%s %s ?", quoted, op))
+		vars = append(vars, m[column])
+	}
+	return strings.Join(conds, " AND "), vars, nil
+}
+
+// buildNull 构建 NULL 判断条件，多个列之间用 AND 连接
+func buildNull(columns []string, op string, table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	var conds []string
+	for _, column := range columns {
+		quoted, err := quoteColumn(column, table, d)
+		if err != nil {
+			return "", nil, err
+		}
+		conds = append(conds, quoted+" "+op)
+	}
+	return strings.Join(conds, " AND "), nil, nil
+}
+
+// buildJoin 用 op 连接多个子表达式，子表达式多于一个时用括号包裹
+func buildJoin(exprs []Expression, op string, table *schema.Schema, d dialect.Dialect) (string, []interface{}, error) {
+	var conds []string
+	var vars []interface{}
+	for _, expr := range exprs {
+		sql, v, err := expr.Build(table, d)
+		if err != nil {
+			return "", nil, err
+		}
+		if sql == "" {
+			continue
+		}
+		conds = append(conds, sql)
+		vars = append(vars, v...)
+	}
+	if len(conds) > 1 {
+		for i := range conds {
+			conds[i] = "(" + conds[i] + ")"
+		}
+	}
+	return strings.Join(conds, " "+op+" "), vars, nil
+}
+
+// quoteColumn 校验列名存在于 table 中，并返回加上引号的列名
+func quoteColumn(column string, table *schema.Schema, d dialect.Dialect) (string, error) {
+	if table != nil && table.GetField(column) == nil {
+		return "", fmt.Errorf("unknown column %s in table %s", column, table.Name)
+	}
+	return d.Quote(column), nil
+}
+
+// sortedKeys 返回按字典序排列的键，保证生成的 SQL 语句和参数顺序稳定
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
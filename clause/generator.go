@@ -2,10 +2,11 @@ package clause
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
-// generator 是一个函数类型，用于生成 SQL 语句和对应的参数
+// Generator 是一个函数类型，用于生成 SQL 语句和对应的参数
 //
 // 参数:
 // values: 可变参数，用于生成 SQL 语句的值
@@ -13,14 +14,14 @@ import (
 // 返回值:
 // string: 生成的 SQL 语句
 // []interface{}: SQL 语句对应的参数
-type generator func(values ...interface{}) (string, []interface{})
+type Generator func(values ...interface{}) (string, []interface{})
 
-// generators 存储了不同类型的 SQL 语句生成器
-var generators map[Type]generator
+// generators 存储了不同类型的 SQL 语句生成器，自定义类型通过 Register 添加
+var generators map[Type]Generator
 
 // init 用于初始化 generators
 func init() {
-	generators = make(map[Type]generator)
+	generators = make(map[Type]Generator)
 	generators[INSERT] = _insert
 	generators[VALUES] = _values
 	generators[SELECT] = _select
@@ -30,6 +31,12 @@ func init() {
 	generators[UPDATE] = _update
 	generators[DELETE] = _delete
 	generators[COUNT] = _count
+	generators[GROUPBY] = _groupBy
+	generators[HAVING] = _having
+	generators[COMPOUND] = _compound
+	generators[WITH] = _with
+	generators[LOCK] = _lock
+	generators[RETURNING] = _returning
 }
 
 // genBindVars 生成指定数量的占位符
@@ -99,18 +106,27 @@ func _values(values ...interface{}) (string, []interface{}) {
 // _select 生成 SELECT 语句
 //
 // 参数:
-// values: 可变参数，第一个参数是表名，第二个参数是字段名列表
+// values: 可变参数，第一个参数是表名，第二个参数是字段名列表，可选的第三个参数为 true 时去重，
+// 之后的参数是字段列表和表名中占位符的值
 //
 // 返回值:
 // string: 生成的 SELECT 语句
-// []interface{}: 空的参数列表
+// []interface{}: 字段列表和表名中占位符的值
 //
 // _select("users", []string{"Name", "Age"}) => "SELECT Name, Age FROM users"
+// _select("users", []string{"Name"}, true) => "SELECT DISTINCT Name FROM users"
 func _select(values ...interface{}) (string, []interface{}) {
 	// SELECT $fields FROM $tableName
 	tableName := values[0]
 	fields := strings.Join(values[1].([]string), ", ")
-	return fmt.Sprintf("SELECT %v FROM %s", fields, tableName), []interface{}{}
+	vars := []interface{}{}
+	if len(values) > 3 {
+		vars = values[3:]
+	}
+	if len(values) > 2 && values[2].(bool) {
+		return fmt.Sprintf("SELECT DISTINCT %v FROM %s", fields, tableName), vars
+	}
+	return fmt.Sprintf("SELECT %v FROM %s", fields, tableName), vars
 }
 
 // _limit 生成 LIMIT 语句
@@ -135,15 +151,46 @@ func _limit(values ...interface{}) (string, []interface{}) {
 //
 // 返回值:
 // string: 生成的 WHERE 语句
-// []interface{}: 条件值
+// []interface{}: 条件值，切片会被展开为多个值
 //
 // _where("Name = ?", "Tom") => "WHERE Name = ?", []interface{}{"Tom"}
+// _where("Name IN (?)", []string{"Tom", "Sam"}) => "WHERE Name IN (?, ?)", []interface{}{"Tom", "Sam"}
 func _where(values ...interface{}) (string, []interface{}) {
 	// WHERE $desc
-	desc, vars := values[0], values[1:]
+	desc, vars := ExpandVars(values[0].(string), values[1:])
 	return fmt.Sprintf("WHERE %v", desc), vars
 }
 
+// _groupBy 生成 GROUP BY 语句
+//
+// 参数:
+// values: 可变参数，第一个参数是分组字段
+//
+// 返回值:
+// string: 生成的 GROUP BY 语句
+// []interface{}: 空的参数列表
+//
+// _groupBy("Age") => "GROUP BY Age"
+func _groupBy(values ...interface{}) (string, []interface{}) {
+	return fmt.Sprintf("GROUP BY %v", values[0]), []interface{}{}
+}
+
+// _having 生成 HAVING 语句
+//
+// 参数:
+// values: 可变参数，第一个参数是条件描述，后面的参数是条件值
+//
+// 返回值:
+// string: 生成的 HAVING 语句
+// []interface{}: 条件值，切片会被展开为多个值
+//
+// _having("count(*) > ?", 1) => "HAVING count(*) > ?", []interface{}{1}
+func _having(values ...interface{}) (string, []interface{}) {
+	// HAVING $desc
+	desc, vars := ExpandVars(values[0].(string), values[1:])
+	return fmt.Sprintf("HAVING %v", desc), vars
+}
+
 // _orderBy 生成 ORDER BY 语句
 //
 // 参数:
@@ -161,20 +208,44 @@ func _orderBy(values ...interface{}) (string, []interface{}) {
 // _update 生成 UPDATE 语句
 //
 // 参数:
-// values: 可变参数，第一个参数是表名，第二个参数是字段名和字段值的映射
+// values: 可变参数，第一个参数是表名，第二个参数是字段名和字段值的映射，
+// 可选的第三个参数是字段名的顺序，没有时按字段名的字典序排列
 //
 // 返回值:
 // string: 生成的 UPDATE 语句
 // []interface{}: 字段值
 //
-// _update("users", map[string]interface{}{"Name": "Tom", "Age": 18}) => "UPDATE users SET Name = ?, Age = ?", []interface{}{"Tom", 18}
+// _update("users", map[string]interface{}{"Name": "Tom", "Age": 18}) => "UPDATE users SET Age = ?, Name = ?", []interface{}{18, "Tom"}
+// _update("users", map[string]interface{}{"Name": "Tom", "Age": 18}, []string{"Name", "Age"}) => "UPDATE users SET Name = ?, Age = ?", []interface{}{"Tom", 18}
+// _update("users", map[string]interface{}{"Age": Expr("Age + ?", 1)}) => "UPDATE users SET Age = Age + ?", []interface{}{1}
+// 字段顺序固定，因此同样的更新总是生成同样的 SQL 语句和参数顺序
 // 后面一般会跟 WHERE 子句，没有就是全改，不推荐全改
 func _update(values ...interface{}) (string, []interface{}) {
 	tableName := values[0]
 	m := values[1].(map[string]interface{})
+	var order []string
+	if len(values) > 2 {
+		order = values[2].([]string)
+	} else {
+		order = make([]string, 0, len(m))
+		for key := range m {
+			order = append(order, key)
+		}
+		sort.Strings(order)
+	}
 	var keys []string
 	var vars []interface{}
-	for key, value := range m {
+	for _, key := range order {
+		value, ok := m[key]
+		if !ok {
+			continue
+		}
+		// SQLExpr 作为表达式内嵌到语句中，例如 Age = Age + ?
+		if expr, ok := value.(SQLExpr); ok {
+			keys = append(keys, key+" = "+expr.SQL)
+			vars = append(vars, expr.Vars...)
+			continue
+		}
 		keys = append(keys, key+" = ?")
 		vars = append(vars, value)
 	}
@@ -198,13 +269,93 @@ func _delete(values ...interface{}) (string, []interface{}) {
 // _count 生成 COUNT 语句
 //
 // 参数:
-// values: 可变参数，第一个参数是表名
+// values: 可变参数，第一个参数是表名，之后的参数是表名中占位符的值
 //
 // 返回值:
 // string: 生成的 COUNT 语句
-// []interface{}: 空的参数列表
+// []interface{}: 表名中占位符的值
 //
 // _count("users") => "SELECT count(*) FROM users"
 func _count(values ...interface{}) (string, []interface{}) {
-	return _select(values[0], []string{"count(*)"})
+	return _select(append([]interface{}{values[0], []string{"count(*)"}, false}, values[1:]...)...)
+}
+
+// _compound 生成 UNION、UNION ALL、INTERSECT、EXCEPT 复合查询语句
+//
+// 参数:
+// values: 可变参数，依次为集合运算符和作为运算对象的 Subquery
+//
+// 返回值:
+// string: 生成的复合查询语句
+// []interface{}: 各个子查询的参数
+//
+// 子查询不加括号，因为 SQLite 不允许用括号包裹复合查询的成员；
+// 复合查询之后的 ORDER BY 和 LIMIT 作用于整个复合查询的结果
+//
+// _compound("UNION", Subquery{"SELECT Name FROM Admin", nil}) => "UNION SELECT Name FROM Admin"
+func _compound(values ...interface{}) (string, []interface{}) {
+	var sqls []string
+	var vars []interface{}
+	for i := 0; i+1 < len(values); i += 2 {
+		sub := values[i+1].(Subquery)
+		sqls = append(sqls, fmt.Sprintf("%v %s", values[i], sub.SQL))
+		vars = append(vars, sub.Vars...)
+	}
+	return strings.Join(sqls, " "), vars
+}
+
+// _with 生成 WITH 公用表表达式（CTE）语句
+//
+// 参数:
+// values: 可变参数，第一个参数为 true 时生成 WITH RECURSIVE，之后依次为 CTE 名称和对应的 Subquery
+//
+// 返回值:
+// string: 生成的 WITH 语句
+// []interface{}: 各个 CTE 的参数
+//
+// _with(false, "adults", Subquery{"SELECT * FROM User WHERE Age >= ?", []interface{}{18}})
+// => "WITH adults AS (SELECT * FROM User WHERE Age >= ?)", []interface{}{18}
+func _with(values ...interface{}) (string, []interface{}) {
+	var ctes []string
+	var vars []interface{}
+	for i := 1; i+1 < len(values); i += 2 {
+		sub := values[i+1].(Subquery)
+		ctes = append(ctes, fmt.Sprintf("%v AS (%s)", values[i], sub.SQL))
+		vars = append(vars, sub.Vars...)
+	}
+	if values[0].(bool) {
+		return "WITH RECURSIVE " + strings.Join(ctes, ", "), vars
+	}
+	return "WITH " + strings.Join(ctes, ", "), vars
+}
+
+// _lock 生成行锁语句
+//
+// 参数:
+// values: 可变参数，第一个参数是锁的强度，如 UPDATE、SHARE，可选的第二个参数是等待选项，如 SKIP LOCKED、NOWAIT
+//
+// 返回值:
+// string: 生成的行锁语句
+// []interface{}: 空的参数列表
+//
+// _lock("UPDATE", "SKIP LOCKED") => "FOR UPDATE SKIP LOCKED"
+func _lock(values ...interface{}) (string, []interface{}) {
+	if len(values) > 1 && values[1] != "" {
+		return fmt.Sprintf("FOR %v %v", values[0], values[1]), []interface{}{}
+	}
+	return fmt.Sprintf("FOR %v", values[0]), []interface{}{}
+}
+
+// _returning 生成 RETURNING 语句
+//
+// 参数:
+// values: 可变参数，第一个参数是字段名列表
+//
+// 返回值:
+// string: 生成的 RETURNING 语句
+// []interface{}: 空的参数列表
+//
+// _returning([]string{"Name", "Age"}) => "RETURNING Name, Age"
+func _returning(values ...interface{}) (string, []interface{}) {
+	return fmt.Sprintf("RETURNING %s", strings.Join(values[0].([]string), ", ")), []interface{}{}
 }
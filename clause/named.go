@@ -0,0 +1,67 @@
+package clause
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Named 将 SQL 语句中的命名参数 @name 和 :name 替换为按位置绑定的 ? 占位符
+//
+// 参数:
+// sql: 包含命名参数的 SQL 语句
+// params: 参数名到参数值的映射
+//
+// 返回值:
+// string: 替换后的 SQL 语句
+// []interface{}: 按占位符顺序排列的参数，同名参数重复出现时值也会重复
+// error: 如果参数名在 params 中不存在，返回错误信息
+//
+// 引号中的内容不会被替换，:: 类型转换（如 Postgres 的 Age::text）也不会被当作命名参数。
+// 替换后的语句还会与其他子句拼接，因此这里统一使用 ?，会话在执行语句前通过 Rebind 和 dialect.Dialect.BindVar
+// 将所有的 ? 按顺序转换为数据库的占位符，例如 Postgres 的 $1
+//
+// Named("Age > @age OR Name = :name", map[string]interface{}{"age": 18, "name": "Tom"})
+// => "Age > ? OR Name = ?", []interface{}{18, "Tom"}
+func Named(sql string, params map[string]interface{}) (string, []interface{}, error) {
+	var b strings.Builder
+	var vars []interface{}
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case (c == '@' || c == ':') && i+1 < len(sql) && isNameStart(sql[i+1]) &&
+			!(c == ':' && i > 0 && sql[i-1] == ':'):
+			j := i + 1
+			for j < len(sql) && isNameChar(sql[j]) {
+				j++
+			}
+			name := sql[i+1 : j]
+			value, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("named parameter %s not found", name)
+			}
+			b.WriteByte('?')
+			vars = append(vars, value)
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), vars, nil
+}
+
+// isNameStart 判断 c 是否可以作为参数名的第一个字符
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isNameChar 判断 c 是否可以作为参数名中的字符
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,150 @@
+package clause
+
+import (
+	"fmt"
+	"geeorm/dialect"
+	"sync"
+)
+
+var (
+	// mu 保护 generators、names 和 orders，注册一般发生在 init 中，读取发生在构建语句时
+	mu sync.RWMutex
+	// names 存储子句类型的名称
+	names = map[Type]string{
+		INSERT:    "INSERT",
+		VALUES:    "VALUES",
+		SELECT:    "SELECT",
+		LIMIT:     "LIMIT",
+		WHERE:     "WHERE",
+		ORDERBY:   "ORDER BY",
+		UPDATE:    "UPDATE",
+		DELETE:    "DELETE",
+		COUNT:     "COUNT",
+		GROUPBY:   "GROUP BY",
+		HAVING:    "HAVING",
+		COMPOUND:  "COMPOUND",
+		WITH:      "WITH",
+		LOCK:      "LOCK",
+		RETURNING: "RETURNING",
+	}
+	// nextType 是下一个自定义子句类型的值
+	nextType = RETURNING + 1
+)
+
+// Statement 表示 SQL 语句的种类，每种语句有各自的子句构建顺序
+type Statement int
+
+// 定义 SQL 语句的种类
+const (
+	SelectStatement Statement = iota
+	InsertStatement
+	UpdateStatement
+	DeleteStatement
+	CountStatement
+)
+
+// orders 存储每种语句默认的子句构建顺序
+var orders = map[Statement][]Type{
+	SelectStatement: {WITH, SELECT, WHERE, GROUPBY, HAVING, COMPOUND, ORDERBY, LIMIT, LOCK},
+	InsertStatement: {INSERT, VALUES, RETURNING},
+	UpdateStatement: {WITH, UPDATE, WHERE, RETURNING},
+	DeleteStatement: {WITH, DELETE, WHERE, RETURNING},
+	CountStatement:  {WITH, COUNT, WHERE},
+}
+
+// Orderer 由需要调整子句构建顺序的数据库方言实现
+type Orderer interface {
+	// ClauseOrders 返回语句 stmt 的子句构建顺序，返回 nil 时使用默认顺序
+	ClauseOrders(stmt Statement) []Type
+}
+
+// Register 注册一个自定义的子句类型
+//
+// 参数:
+// name: 子句类型的名称，用于日志和错误信息
+// g: 子句的生成器
+//
+// 返回值:
+// Type: 新的子句类型，需要通过 SetDefaultOrders 或方言的 ClauseOrders 加入到语句的构建顺序中
+//
+// 注册一般发生在 init 中，name 已经被注册（包括内置子句的名称）时 panic
+//
+// 示例:
+//
+//	var USEINDEX = clause.Register("USE INDEX", func(values ...interface{}) (string, []interface{}) {
+//		return fmt.Sprintf("USE INDEX (%v)", values[0]), nil
+//	})
+func Register(name string, g Generator) Type {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, registered := range names {
+		if registered == name {
+			panic(fmt.Sprintf("clause type %s is already registered", name))
+		}
+	}
+	t := nextType
+	nextType++
+	names[t] = name
+	generators[t] = g
+	return t
+}
+
+// SetGenerator 替换已注册子句类型 t 的生成器，例如让内置的 LIMIT 生成方言特有的语法
+//
+// 参数:
+// t: 已注册的子句类型
+// g: 新的生成器
+func SetGenerator(t Type, g Generator) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := generators[t]; !ok {
+		panic(fmt.Sprintf("clause type %v is not registered", t))
+	}
+	generators[t] = g
+}
+
+// String 返回子句类型的名称
+func (t Type) String() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if name, ok := names[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// DefaultOrders 返回语句 stmt 默认的子句构建顺序的副本
+func DefaultOrders(stmt Statement) []Type {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Type(nil), orders[stmt]...)
+}
+
+// SetDefaultOrders 设置语句 stmt 默认的子句构建顺序
+//
+// 示例:
+//
+//	orders := clause.DefaultOrders(clause.SelectStatement)
+//	clause.SetDefaultOrders(clause.SelectStatement, append(orders[:2:2], append([]clause.Type{USEINDEX}, orders[2:]...)...)...)
+func SetDefaultOrders(stmt Statement, types ...Type) {
+	mu.Lock()
+	defer mu.Unlock()
+	orders[stmt] = append([]Type(nil), types...)
+}
+
+// Orders 返回方言 d 下语句 stmt 的子句构建顺序
+//
+// d 实现了 Orderer 且返回非 nil 时使用方言的顺序，否则使用默认顺序
+func Orders(stmt Statement, d dialect.Dialect) []Type {
+	if o, ok := d.(Orderer); ok {
+		if types := o.ClauseOrders(stmt); types != nil {
+			return types
+		}
+	}
+	return DefaultOrders(stmt)
+}
+
+// BuildStatement 按方言 d 下语句 stmt 的子句构建顺序构建 SQL 语句和对应的参数，错误见 Build
+func (c *Clause) BuildStatement(stmt Statement, d dialect.Dialect) (string, []interface{}, error) {
+	return c.Build(Orders(stmt, d)...)
+}
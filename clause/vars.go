@@ -0,0 +1,158 @@
+package clause
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+)
+
+// Subquery 是已经构建好的子查询，作为参数传给 ExpandVars 时会被内嵌到语句中
+type Subquery struct {
+	SQL  string        // 子查询语句
+	Vars []interface{} // 子查询语句对应的参数
+}
+
+// SQLExpr 是带参数的 SQL 表达式，作为值使用时会被原样内嵌到语句中，而不是作为参数绑定
+type SQLExpr struct {
+	SQL  string        // 表达式语句
+	Vars []interface{} // 表达式语句对应的参数
+}
+
+// Expr 创建一个 SQL 表达式，可以作为 Update 的值或者条件语句的参数
+//
+// 参数:
+// sql: 表达式语句
+// vars: 表达式语句中占位符的值
+//
+// 返回值:
+// SQLExpr: SQL 表达式
+//
+// s.Update("Age", clause.Expr("Age + ?", 1)) => "UPDATE User SET Age = Age + ?", []interface{}{1}
+func Expr(sql string, vars ...interface{}) SQLExpr {
+	return SQLExpr{SQL: sql, Vars: vars}
+}
+
+// ExpandVars 将参数中的切片展开为对应数量的占位符，并内嵌参数中的子查询
+//
+// 参数:
+// sql: 包含 ? 占位符的 SQL 语句
+// vars: 占位符对应的参数
+//
+// 返回值:
+// string: 展开后的 SQL 语句
+// []interface{}: 展开后的参数
+//
+// 引号中的 ? 不会被当作占位符；[]byte、数组和实现了 driver.Valuer 的类型不会被展开；
+// 空切片展开为没有结果的子查询 SELECT NULL WHERE 1 = 0，使 IN (?) 恒不成立、NOT IN (?) 恒成立；
+// Subquery 参数替换为用括号包裹的子查询语句，SQLExpr 参数替换为表达式语句，它们的参数按位置合并
+//
+// ExpandVars("Name IN (?) AND Age > ?", []interface{}{[]string{"Tom", "Sam"}, 18})
+// => "Name IN (?, ?) AND Age > ?", []interface{}{"Tom", "Sam", 18}
+// ExpandVars("Age > ?", []interface{}{Subquery{"SELECT AVG(Age) FROM User WHERE Name <> ?", []interface{}{"Tom"}}})
+// => "Age > (SELECT AVG(Age) FROM User WHERE Name <> ?)", []interface{}{"Tom"}
+func ExpandVars(sql string, vars []interface{}) (string, []interface{}) {
+	expand := false
+	for _, v := range vars {
+		_, isSubquery := v.(Subquery)
+		_, isExpr := v.(SQLExpr)
+		if _, ok := expandable(v); ok || isSubquery || isExpr {
+			expand = true
+			break
+		}
+	}
+	if !expand {
+		return sql, vars
+	}
+	var b strings.Builder
+	var expanded []interface{}
+	var quote byte
+	idx := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?' && idx < len(vars):
+			v := vars[idx]
+			idx++
+			if sub, ok := v.(Subquery); ok {
+				b.WriteString("(" + sub.SQL + ")")
+				expanded = append(expanded, sub.Vars...)
+				continue
+			}
+			if expr, ok := v.(SQLExpr); ok {
+				b.WriteString(expr.SQL)
+				expanded = append(expanded, expr.Vars...)
+				continue
+			}
+			if rv, ok := expandable(v); ok {
+				if rv.Len() == 0 {
+					// IN (NULL) 恒不成立，但 NOT IN (NULL) 同样不成立，空的子查询对两者都能得到正确的结果
+					b.WriteString(emptySet)
+					continue
+				}
+				b.WriteString(genBindVars(rv.Len()))
+				for j := 0; j < rv.Len(); j++ {
+					expanded = append(expanded, rv.Index(j).Interface())
+				}
+				continue
+			}
+			expanded = append(expanded, v)
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), append(expanded, vars[idx:]...)
+}
+
+// Rebind 将 SQL 语句中的 ? 占位符依次替换为 bindVar 返回的占位符，引号中的 ? 不会被替换
+//
+// 参数:
+// sql: 包含 ? 占位符的 SQL 语句
+// bindVar: 返回第 i 个（从 1 开始）参数的占位符，一般是 dialect.Dialect.BindVar
+//
+// 返回值:
+// string: 替换后的 SQL 语句
+//
+// Rebind("Name = ? AND Note <> '?' AND Age > ?", func(i int) string { return fmt.Sprintf("$%d", i) })
+// => "Name = $1 AND Note <> '?' AND Age > $2"
+func Rebind(sql string, bindVar func(i int) string) string {
+	var b strings.Builder
+	var quote byte
+	n := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteString(bindVar(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// emptySet 是空切片展开后的内容，是一个没有结果的子查询
+const emptySet = "SELECT NULL WHERE 1 = 0"
+
+// expandable 判断参数 v 是否是需要展开的切片，[]byte 和数组（例如 [16]byte 的 UUID）作为单个参数
+func expandable(v interface{}) (reflect.Value, bool) {
+	if _, ok := v.(driver.Valuer); ok {
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		return rv, true
+	}
+	return reflect.Value{}, false
+}
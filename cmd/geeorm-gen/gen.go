@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"geeorm/dialect"
+	"geeorm/migrate"
+	"go/format"
+	"go/token"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 可以为 NULL 的列对应的字段类型
+const (
+	nullPointer = "pointer" // 指针，例如 *string
+	nullSQL     = "sql"     // sql.Null* 类型，例如 sql.NullString
+)
+
+// nullTypes 是基本类型对应的 sql.Null* 类型，int 对应 sql.Null[int]，以便 DataTypeOf 仍然生成 integer
+var nullTypes = map[reflect.Type]string{
+	reflect.TypeOf(""):          "sql.NullString",
+	reflect.TypeOf(0):           "sql.Null[int]",
+	reflect.TypeOf(int64(0)):    "sql.NullInt64",
+	reflect.TypeOf(float64(0)):  "sql.NullFloat64",
+	reflect.TypeOf(false):       "sql.NullBool",
+	reflect.TypeOf(time.Time{}): "sql.NullTime",
+}
+
+// generator 读取数据库中的表结构，生成对应的模型
+type generator struct {
+	db      *sql.DB
+	dialect dialect.Dialect
+	null    string          // 可以为 NULL 的列对应的字段类型，nullPointer 或 nullSQL
+	imports map[string]bool // 生成的代码需要导入的包
+}
+
+// field 是生成的结构体中的一个字段
+type field struct {
+	name        string // 字段名
+	column      string // 列名，与字段名不同时在标签中通过 column 选项声明
+	typ         string
+	constraints []string // 标签中列定义的约束，例如 NOT NULL
+	options     []string // 标签中的选项，例如 index:idx_name
+}
+
+// tag 返回字段的结构体标签，没有约束和选项时为空
+func (f *field) tag() string {
+	parts := f.options
+	if len(f.constraints) > 0 {
+		parts = append([]string{strings.Join(f.constraints, " ")}, parts...)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	tag := "geeorm:" + strconv.Quote(strings.Join(parts, ";"))
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// generate 生成数据库中的表 tables 对应的模型代码
+//
+// 参数:
+// db: 数据库连接
+// d: 数据库方言
+// pkg: 生成的代码所在的包名
+// tables: 要生成模型的表，为空时生成除迁移记录表之外的所有表
+// null: 可以为 NULL 的列对应的字段类型，nullPointer 或 nullSQL
+//
+// 返回值:
+// []byte: 经过 go/format 格式化的代码
+// error: 如果读取表结构失败，或者表名、列名不能转换为不重复的 Go 标识符，返回错误信息
+//
+// 表名和列名通过 goName 转换为导出的 Go 标识符，例如 created_at => CreatedAt；
+// 与原名不同时，结构体通过 TableName 方法、字段通过标签的 column 选项声明原来的表名和列名。
+// 名称不是由字母、数字和下划线组成的表或列无法在生成的 SQL 中使用，此时返回错误
+func generate(db *sql.DB, d dialect.Dialect, pkg string, tables []string, null string) ([]byte, error) {
+	if null != nullPointer && null != nullSQL {
+		return nil, fmt.Errorf("invalid null style %s, expect %s or %s", null, nullPointer, nullSQL)
+	}
+	if len(tables) == 0 {
+		all, err := d.Tables(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range all {
+			if table != migrate.HistoryTable {
+				tables = append(tables, table)
+			}
+		}
+	}
+	g := &generator{db: db, dialect: d, null: null, imports: make(map[string]bool)}
+	var body bytes.Buffer
+	structs := make(map[string]string, len(tables))
+	for _, table := range tables {
+		name, err := goName(table)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", table, err)
+		}
+		if other, ok := structs[name]; ok {
+			return nil, fmt.Errorf("tables %s and %s are both mapped to struct %s", other, table, name)
+		}
+		structs[name] = table
+		if err := g.writeTable(&body, table, name); err != nil {
+			return nil, fmt.Errorf("table %s: %w", table, err)
+		}
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// 由 geeorm-gen 根据数据库中的表结构生成，可以按需修改\n\npackage %s\n\n", pkg)
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			imports = append(imports, strconv.Quote(path))
+		}
+		sort.Strings(imports)
+		fmt.Fprintf(&out, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// writeTable 将表 table 对应的名为 name 的结构体写入 w
+func (g *generator) writeTable(w *bytes.Buffer, table, name string) error {
+	columns, err := g.dialect.ColumnTypes(g.db, table)
+	if err != nil {
+		return err
+	}
+	indexes, err := g.dialect.Indexes(g.db, table)
+	if err != nil {
+		return err
+	}
+	constraints, err := g.dialect.Constraints(g.db, table)
+	if err != nil {
+		return err
+	}
+	var fields []*field
+	var notes []string
+	byName := make(map[string]*field)
+	byField := make(map[string]string)
+	for _, c := range columns {
+		f, err := g.column(c)
+		if err != nil {
+			return err
+		}
+		if other, ok := byField[f.name]; ok {
+			return fmt.Errorf("columns %s and %s are both mapped to field %s", other, c.Name, f.name)
+		}
+		fields = append(fields, f)
+		byName[c.Name] = f
+		byField[f.name] = c.Name
+	}
+	// 某个列没有对应的字段时，涉及该列的约束和索引不能在标签中声明
+	lookup := func(names []string) []*field {
+		fs := make([]*field, len(names))
+		for i, name := range names {
+			if fs[i] = byName[name]; fs[i] == nil {
+				return nil
+			}
+		}
+		return fs
+	}
+	for _, c := range constraints {
+		fs := lookup(c.Columns)
+		switch {
+		case c.Type == dialect.CheckConstraint:
+			g.check(fields, fs, c)
+		case fs == nil:
+			notes = append(notes, fmt.Sprintf("%s 约束 (%s) 涉及没有生成字段的列", c.Type, strings.Join(c.Columns, ", ")))
+		case c.Type == dialect.PrimaryKeyConstraint && len(fs) > 1:
+			notes = append(notes, fmt.Sprintf("复合主键 (%s) 不能在标签中声明", strings.Join(c.Columns, ", ")))
+		case c.Type == dialect.PrimaryKeyConstraint:
+			fs[0].constraints = append([]string{"PRIMARY KEY"}, fs[0].constraints...)
+		case c.Type == dialect.UniqueConstraint && len(fs) == 1:
+			fs[0].constraints = append(fs[0].constraints, "UNIQUE")
+		case c.Type == dialect.UniqueConstraint:
+			name := "uq_" + table + "_" + strings.Join(c.Columns, "_")
+			for i, f := range fs {
+				f.options = append(f.options, fmt.Sprintf("uniqueIndex:%s,priority:%d", name, i+1))
+			}
+		case c.Type == dialect.ForeignKeyConstraint && len(fs) > 1:
+			notes = append(notes, fmt.Sprintf("复合外键 (%s) 不能在标签中声明", strings.Join(c.Columns, ", ")))
+		case c.Type == dialect.ForeignKeyConstraint:
+			fs[0].constraints = append(fs[0].constraints, referencesSQL(c))
+		}
+	}
+	for _, index := range indexes {
+		// 约束自动创建的索引已经通过约束声明
+		if index.Origin != "c" {
+			continue
+		}
+		if fs := lookup(index.Columns); fs != nil {
+			g.index(fs, index)
+		} else {
+			notes = append(notes, fmt.Sprintf("索引 %s 包含表达式或者没有生成字段的列: %s", index.Name, index.SQL))
+		}
+	}
+
+	fmt.Fprintf(w, "// %s 对应数据库中的表 %s\n", name, table)
+	for _, note := range notes {
+		fmt.Fprintf(w, "//\n// 注意: %s\n", note)
+	}
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(w, "\t%s %s %s\n", f.name, f.typ, f.tag())
+	}
+	fmt.Fprint(w, "}\n\n")
+	if name != table {
+		fmt.Fprintf(w, "// TableName 返回 %s 对应的表名\nfunc (%s) TableName() string {\n\treturn %s\n}\n\n", name, name, strconv.Quote(table))
+	}
+	return nil
+}
+
+// sqlNameRegexp 匹配可以在生成的 SQL 中直接使用的表名或列名
+var sqlNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// initialisms 是字段名中保持全部大写的缩写
+var initialisms = map[string]string{
+	"id": "ID", "ip": "IP", "url": "URL", "uri": "URI", "uuid": "UUID", "api": "API", "http": "HTTP", "json": "JSON", "sql": "SQL",
+}
+
+// goName 将表名或列名转换为导出的 Go 标识符
+//
+// 名称按下划线拆分，每一部分首字母大写，initialisms 中的缩写全部大写，已经是导出标识符的部分保持不变
+//
+// 示例:
+// created_at => CreatedAt, user_id => UserID, AuthorID => AuthorID
+func goName(name string) (string, error) {
+	if !sqlNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("name %q contains characters other than letters, digits and underscores", name)
+	}
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if upper, ok := initialisms[strings.ToLower(part)]; ok {
+			b.WriteString(upper)
+		} else if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	if !token.IsIdentifier(b.String()) || !token.IsExported(b.String()) {
+		return "", fmt.Errorf("name %q cannot be mapped to an exported Go identifier", name)
+	}
+	return b.String(), nil
+}
+
+// column 返回列 c 对应的字段，包括类型、NOT NULL、默认值和生成列
+func (g *generator) column(c dialect.Column) (*field, error) {
+	name, err := goName(c.Name)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %w", c.Name, err)
+	}
+	f := &field{name: name, column: c.Name, typ: g.typeOf(c)}
+	if name != c.Name {
+		f.options = append(f.options, "column:"+c.Name)
+	}
+	if c.NotNull && !c.PrimaryKey {
+		f.constraints = append(f.constraints, "NOT NULL")
+	}
+	if c.Default != "" {
+		f.constraints = append(f.constraints, "DEFAULT "+c.Default)
+	}
+	if c.Generated != "" {
+		opt := "generated:" + c.Generated
+		if c.Stored {
+			opt += ",stored"
+		}
+		f.options = append(f.options, opt)
+	}
+	return f, nil
+}
+
+// typeOf 返回列 c 对应的字段类型，可以为 NULL 的列使用指针或 sql.Null* 类型，[]byte 本身可以表示 NULL
+func (g *generator) typeOf(c dialect.Column) string {
+	typ := g.dialect.GoTypeOf(c)
+	name := typ.String()
+	if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+		return "[]byte"
+	}
+	if !c.NotNull && !c.PrimaryKey && g.null == nullSQL {
+		if null, ok := nullTypes[typ]; ok {
+			g.imports["database/sql"] = true
+			return null
+		}
+	}
+	if typ.PkgPath() != "" {
+		g.imports[typ.PkgPath()] = true
+	}
+	if c.NotNull || c.PrimaryKey {
+		return name
+	}
+	return "*" + name
+}
+
+// index 在索引 index 的列对应的字段 fs 上声明该索引，复合索引按列的顺序设置 priority
+func (g *generator) index(fs []*field, index dialect.Index) {
+	key := "index"
+	if index.Unique {
+		key = "uniqueIndex"
+	}
+	for i, f := range fs {
+		opt := key + ":" + index.Name
+		if len(fs) > 1 {
+			opt += ",priority:" + strconv.Itoa(i+1)
+		}
+		if where := indexWhere(index.SQL); where != "" && i == 0 {
+			opt += ",where:" + where
+		}
+		f.options = append(f.options, opt)
+	}
+}
+
+// whereRegexp 匹配 CREATE INDEX 语句中部分索引的条件
+var whereRegexp = regexp.MustCompile(`(?is)\)\s*WHERE\s+(.+)$`)
+
+// indexWhere 返回创建索引的语句中部分索引的条件，不是部分索引时为空
+func indexWhere(sql string) string {
+	if match := whereRegexp.FindStringSubmatch(sql); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return ""
+}
+
+// check 在字段上声明 CHECK 约束 c：列的约束写入该列的约束中，表约束作为 check 选项，
+// 写在表达式中第一个出现的字段上，都没有出现时写在第一个字段上
+func (g *generator) check(fields, fs []*field, c dialect.Constraint) {
+	if len(fs) == 1 {
+		fs[0].constraints = append(fs[0].constraints, fmt.Sprintf("CHECK (%s)", c.Expression))
+		return
+	}
+	if len(fields) == 0 {
+		return
+	}
+	target := fields[0]
+	for _, f := range fields {
+		if regexp.MustCompile(`\b` + f.column + `\b`).MatchString(c.Expression) {
+			target = f
+			break
+		}
+	}
+	opt := "check:" + c.Expression
+	if c.Name != "" {
+		opt = "check:" + c.Name + "," + c.Expression
+	}
+	target.options = append(target.options, opt)
+}
+
+// referencesSQL 返回外键约束 c 对应的列约束，例如 REFERENCES User(Name) ON DELETE CASCADE
+func referencesSQL(c dialect.Constraint) string {
+	sql := "REFERENCES " + c.RefTable
+	if len(c.RefColumns) > 0 && c.RefColumns[0] != "" {
+		sql += "(" + c.RefColumns[0] + ")"
+	}
+	if c.OnDelete != "" && !strings.EqualFold(c.OnDelete, "NO ACTION") {
+		sql += " ON DELETE " + c.OnDelete
+	}
+	if c.OnUpdate != "" && !strings.EqualFold(c.OnUpdate, "NO ACTION") {
+		sql += " ON UPDATE " + c.OnUpdate
+	}
+	return sql
+}
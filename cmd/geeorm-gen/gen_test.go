@@ -0,0 +1,147 @@
+package main
+
+import (
+	"database/sql"
+	"geeorm/dialect"
+	"geeorm/session"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const legacySchema = `
+CREATE TABLE Author(ID integer PRIMARY KEY, Name varchar(100) NOT NULL UNIQUE, Bio text, Born datetime, Rating real DEFAULT 0);
+CREATE TABLE Book(ID bigint PRIMARY KEY, AuthorID integer REFERENCES Author(ID) ON DELETE CASCADE, Title text NOT NULL,
+	Price decimal(10,2), Pages integer CHECK (Pages > 0), Total real AS (Price * Pages) STORED,
+	CONSTRAINT chk_price CHECK (Price >= 0), UNIQUE (Title, AuthorID));
+CREATE INDEX idx_book_title ON Book(Title) WHERE Price > 0;
+CREATE INDEX idx_lower ON Book(lower(Title));
+CREATE TABLE legacy_items(id integer);
+`
+
+func openLegacy(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatal("failed to open database", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err = db.Exec(legacySchema); err != nil {
+		t.Fatal("failed to create tables", err)
+	}
+	return db
+}
+
+func TestGenerate(t *testing.T) {
+	db := openLegacy(t)
+	d, _ := dialect.GetDialect("sqlite3")
+	code, err := generate(db, d, "model", nil, nullPointer)
+	if err != nil {
+		t.Fatal("failed to generate models", err)
+	}
+	expect := "// 由 geeorm-gen 根据数据库中的表结构生成，可以按需修改\n\npackage model\n\nimport (\n\t\"time\"\n)\n\n" +
+		"// Author 对应数据库中的表 Author\ntype Author struct {\n" +
+		"\tID     int    `geeorm:\"PRIMARY KEY\"`\n" +
+		"\tName   string `geeorm:\"NOT NULL UNIQUE\"`\n" +
+		"\tBio    *string\n" +
+		"\tBorn   *time.Time\n" +
+		"\tRating *float64 `geeorm:\"DEFAULT 0\"`\n}\n\n" +
+		"// Book 对应数据库中的表 Book\n//\n" +
+		"// 注意: 索引 idx_lower 包含表达式或者没有生成字段的列: CREATE INDEX idx_lower ON Book(lower(Title))\ntype Book struct {\n" +
+		"\tID       int64    `geeorm:\"PRIMARY KEY\"`\n" +
+		"\tAuthorID *int     `geeorm:\"REFERENCES Author(ID) ON DELETE CASCADE;uniqueIndex:uq_Book_Title_AuthorID,priority:2\"`\n" +
+		"\tTitle    string   `geeorm:\"NOT NULL;uniqueIndex:uq_Book_Title_AuthorID,priority:1;index:idx_book_title,where:Price > 0\"`\n" +
+		"\tPrice    *float64 `geeorm:\"check:chk_price,Price >= 0\"`\n" +
+		"\tPages    *int     `geeorm:\"CHECK (Pages > 0)\"`\n" +
+		"\tTotal    *float64 `geeorm:\"generated:Price * Pages,stored\"`\n}\n\n" +
+		"// LegacyItems 对应数据库中的表 legacy_items\ntype LegacyItems struct {\n" +
+		"\tID *int `geeorm:\"column:id\"`\n}\n\n" +
+		"// TableName 返回 LegacyItems 对应的表名\nfunc (LegacyItems) TableName() string {\n\treturn \"legacy_items\"\n}\n"
+	if string(code) != expect {
+		t.Fatalf("unexpected code:\n%s", code)
+	}
+}
+
+func TestGenerate_NullTypes(t *testing.T) {
+	db := openLegacy(t)
+	d, _ := dialect.GetDialect("sqlite3")
+	code, err := generate(db, d, "model", []string{"Author"}, nullSQL)
+	if err != nil {
+		t.Fatal("failed to generate models", err)
+	}
+	expect := "// 由 geeorm-gen 根据数据库中的表结构生成，可以按需修改\n\npackage model\n\nimport (\n\t\"database/sql\"\n)\n\n" +
+		"// Author 对应数据库中的表 Author\ntype Author struct {\n" +
+		"\tID     int    `geeorm:\"PRIMARY KEY\"`\n" +
+		"\tName   string `geeorm:\"NOT NULL UNIQUE\"`\n" +
+		"\tBio    sql.NullString\n" +
+		"\tBorn   sql.NullTime\n" +
+		"\tRating sql.NullFloat64 `geeorm:\"DEFAULT 0\"`\n}\n"
+	if string(code) != expect {
+		t.Fatalf("unexpected code:\n%s", code)
+	}
+	if _, err = generate(db, d, "model", nil, "value"); err == nil {
+		t.Fatal("expect error for invalid null style")
+	}
+}
+
+// Users 和 Orders 与 TestGenerate_Lowercase 生成的代码相同，用于检查生成的模型与原来的表一致
+type Users struct {
+	ID        int        `geeorm:"PRIMARY KEY;column:id"`
+	Name      string     `geeorm:"NOT NULL;column:name"`
+	CreatedAt *time.Time `geeorm:"column:created_at"`
+}
+
+func (Users) TableName() string {
+	return "users"
+}
+
+type Orders struct {
+	ID     int  `geeorm:"PRIMARY KEY;column:id"`
+	UserID *int `geeorm:"REFERENCES users(id);column:user_id"`
+	Total  *float64
+}
+
+func TestGenerate_Lowercase(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "lower.db"))
+	if err != nil {
+		t.Fatal("failed to open database", err)
+	}
+	defer func() { _ = db.Close() }()
+	_, err = db.Exec(`CREATE TABLE users(id integer PRIMARY KEY, name text NOT NULL, created_at datetime);
+CREATE TABLE Orders(id integer PRIMARY KEY, user_id integer REFERENCES users(id), Total real);`)
+	if err != nil {
+		t.Fatal("failed to create tables", err)
+	}
+	d, _ := dialect.GetDialect("sqlite3")
+	code, err := generate(db, d, "model", nil, nullPointer)
+	if err != nil {
+		t.Fatal("failed to generate models", err)
+	}
+	expect := "// 由 geeorm-gen 根据数据库中的表结构生成，可以按需修改\n\npackage model\n\nimport (\n\t\"time\"\n)\n\n" +
+		"// Orders 对应数据库中的表 Orders\ntype Orders struct {\n" +
+		"\tID     int  `geeorm:\"PRIMARY KEY;column:id\"`\n" +
+		"\tUserID *int `geeorm:\"REFERENCES users(id);column:user_id\"`\n" +
+		"\tTotal  *float64\n}\n\n" +
+		"// Users 对应数据库中的表 users\ntype Users struct {\n" +
+		"\tID        int        `geeorm:\"PRIMARY KEY;column:id\"`\n" +
+		"\tName      string     `geeorm:\"NOT NULL;column:name\"`\n" +
+		"\tCreatedAt *time.Time `geeorm:\"column:created_at\"`\n}\n\n" +
+		"// TableName 返回 Users 对应的表名\nfunc (Users) TableName() string {\n\treturn \"users\"\n}\n"
+	if string(code) != expect {
+		t.Fatalf("unexpected code:\n%s", code)
+	}
+	for _, model := range []interface{}{&Users{}, &Orders{}} {
+		plan, err := session.New(db, d).Model(model).PlanMigration()
+		if err != nil || len(plan.Statements) != 0 {
+			t.Fatal("generated model should match the table", err, plan.Diffs[0])
+		}
+	}
+	if _, err = db.Exec(`CREATE TABLE "weird col"(id integer)`); err != nil {
+		t.Fatal("failed to create table", err)
+	}
+	if _, err = generate(db, d, "model", nil, nullPointer); err == nil {
+		t.Fatal("expect error for name that cannot be mapped to a Go identifier")
+	}
+}
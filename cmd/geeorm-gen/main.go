@@ -0,0 +1,68 @@
+// geeorm-gen 读取已有数据库中的表结构，生成带有 geeorm 标签的模型结构体
+//
+// 用法:
+//
+//	geeorm-gen -dsn legacy.db [-driver sqlite3] [-tables User,Book] [-pkg model] [-null pointer|sql] [-o model/model.go]
+//
+// 生成的结构体以表名命名，字段以列名命名，标签中声明主键、NOT NULL、默认值、UNIQUE、外键、CHECK 约束、生成列和索引；
+// 可以为 NULL 的列使用指针（-null pointer，默认）或 sql.Null* 类型（-null sql）。
+// geeorm 通过 Go 类型推导列的类型，声明的类型与 dialect.Dialect.DataTypeOf 生成的类型不同时（例如 VARCHAR(255)），
+// 之后调用 Migrate 会按模型重建这些列。
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"geeorm/dialect"
+	"os"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	driver := flag.String("driver", "sqlite3", "数据库驱动名称")
+	dsn := flag.String("dsn", "", "数据库连接字符串，例如 SQLite 的数据库文件")
+	tables := flag.String("tables", "", "以逗号分隔的表名，为空时生成所有的表")
+	pkg := flag.String("pkg", "model", "生成的代码所在的包名")
+	null := flag.String("null", nullPointer, "可以为 NULL 的列对应的字段类型：pointer 或 sql")
+	out := flag.String("o", "", "输出文件，为空时输出到标准输出")
+	flag.Parse()
+	if *dsn == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*driver, *dsn, *tables, *pkg, *null, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "geeorm-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// run 连接数据库，生成模型代码并写入 out
+func run(driver, dsn, tables, pkg, null, out string) error {
+	d, ok := dialect.GetDialect(driver)
+	if !ok {
+		return fmt.Errorf("dialect %s not found", driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+	var names []string
+	for _, name := range strings.Split(tables, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	code, err := generate(db, d, pkg, names, null)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0o644)
+}
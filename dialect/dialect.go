@@ -16,6 +16,15 @@ type Dialect interface {
 	// string: 数据库中的数据类型
 	DataTypeOf(typ reflect.Value) string
 
+	// GoTypeOf 返回表中的列对应的 Go 语言类型，是 DataTypeOf 的逆映射，用于从已有的表生成模型
+	//
+	// 参数:
+	// column: 表中的列
+	//
+	// 返回值:
+	// reflect.Type: Go 语言类型，不考虑列是否可以为 NULL
+	GoTypeOf(column Column) reflect.Type
+
 	// TableExistSQL 返回检查表是否存在的 SQL 语句
 	//
 	// 参数:
@@ -25,6 +34,156 @@ type Dialect interface {
 	// string: SQL 查询语句
 	// []interface{}: 查询参数
 	TableExistSQL(tableName string) (string, []interface{})
+
+	// TableObjectsSQL 返回查询表上的索引和触发器定义语句的 SQL 语句，重建表之后用于重新创建它们
+	//
+	// 参数:
+	// tableName: 表名
+	//
+	// 返回值:
+	// string: SQL 查询语句，查询结果只有一列，即 CREATE INDEX、CREATE TRIGGER 等语句
+	// []interface{}: 查询参数
+	TableObjectsSQL(tableName string) (string, []interface{})
+
+	// MaxPlaceholders 返回单条 SQL 语句中允许使用的最大占位符数量
+	//
+	// 返回值:
+	// int: 最大占位符数量，批量插入时按该值拆分语句
+	MaxPlaceholders() int
+
+	// BindVar 返回第 i 个参数的占位符
+	//
+	// 参数:
+	// i: 参数的序号，从 1 开始
+	//
+	// 返回值:
+	// string: 占位符，例如 SQLite 和 MySQL 的 ?、Postgres 的 $1；
+	// 生成的语句统一使用 ?，执行前按该方法转换为数据库的占位符
+	BindVar(i int) string
+
+	// Quote 返回加上引号的标识符（表名、列名）
+	//
+	// 参数:
+	// name: 标识符
+	//
+	// 返回值:
+	// string: 加上引号的标识符
+	Quote(name string) string
+
+	// Supports 返回数据库是否支持可选特性 feature
+	//
+	// 参数:
+	// feature: 可选特性
+	//
+	// 返回值:
+	// bool: 支持返回 true，否则返回 false，不支持的特性在生成 SQL 语句时会被省略或模拟
+	Supports(feature Feature) bool
+
+	// Tables 读取数据库中所有的表名
+	//
+	// 参数:
+	// db: 执行查询的数据库连接或事务
+	//
+	// 返回值:
+	// []string: 按名称排序的表名，不包括数据库内部使用的表
+	// error: 如果查询失败，返回错误信息
+	Tables(db Queryer) ([]string, error)
+
+	// ColumnTypes 读取表中各列的定义
+	//
+	// 参数:
+	// db: 执行查询的数据库连接或事务
+	// tableName: 表名
+	//
+	// 返回值:
+	// []Column: 按表中顺序排列的列
+	// error: 如果查询失败，返回错误信息
+	ColumnTypes(db Queryer, tableName string) ([]Column, error)
+
+	// Indexes 读取表上的索引，包括约束自动创建的索引
+	//
+	// 参数:
+	// db: 执行查询的数据库连接或事务
+	// tableName: 表名
+	//
+	// 返回值:
+	// []Index: 表上的索引
+	// error: 如果查询失败，返回错误信息
+	Indexes(db Queryer, tableName string) ([]Index, error)
+
+	// Constraints 读取表上的主键、唯一、外键和 CHECK 约束
+	//
+	// 参数:
+	// db: 执行查询的数据库连接或事务
+	// tableName: 表名
+	//
+	// 返回值:
+	// []Constraint: 表上的约束
+	// error: 如果查询失败，返回错误信息
+	Constraints(db Queryer, tableName string) ([]Constraint, error)
+
+	// ForeignKeysSQL 返回在当前连接上开启或关闭外键约束检查的语句
+	//
+	// 参数:
+	// enabled: 开启为 true，关闭为 false
+	//
+	// 返回值:
+	// string: SQL 语句，数据库总是检查外键约束时返回空字符串
+	ForeignKeysSQL(enabled bool) string
+
+	// ForeignKeysEnabledSQL 返回查询当前连接是否开启了外键约束检查的语句
+	//
+	// 返回值:
+	// string: SQL 查询语句，结果为一行一列的布尔值；数据库总是检查外键约束时返回空字符串
+	ForeignKeysEnabledSQL() string
+
+	// ForeignKeyCheckSQL 返回查询违反外键约束的记录的语句，在关闭外键约束检查修改表结构后使用
+	//
+	// 返回值:
+	// string: SQL 查询语句，查询结果不为空时表示存在违反外键约束的记录；不需要检查时返回空字符串
+	ForeignKeyCheckSQL() string
+}
+
+// Feature 表示数据库方言可能支持的可选特性
+type Feature int
+
+// 定义可选特性
+const (
+	// RowLocking 行锁，即 SELECT ... FOR UPDATE / FOR SHARE 以及 SKIP LOCKED / NOWAIT
+	RowLocking Feature = iota
+	// Returning INSERT、UPDATE、DELETE 语句的 RETURNING 子句
+	Returning
+	// DropColumn ALTER TABLE ... DROP COLUMN 语句，不支持时通过重建表删除列
+	DropColumn
+)
+
+// Detector 是可选接口，特性取决于数据库版本的方言实现该接口
+type Detector interface {
+	// Detect 读取数据库的版本
+	//
+	// 参数:
+	// db: 数据库连接
+	//
+	// 返回值:
+	// Dialect: 按该版本判断 Supports 的方言，不会修改已注册的方言
+	// error: 如果查询失败，返回错误信息
+	Detect(db Queryer) (Dialect, error)
+}
+
+// Detect 返回按数据库 db 的版本判断特性的方言
+//
+// 参数:
+// d: 已注册的方言
+// db: 数据库连接
+//
+// 返回值:
+// Dialect: d 实现了 Detector 时返回 Detect 的结果，否则返回 d 本身
+// error: 如果读取数据库版本失败，返回错误信息
+func Detect(d Dialect, db Queryer) (Dialect, error) {
+	if detector, ok := d.(Detector); ok {
+		return detector.Detect(db)
+	}
+	return d, nil
 }
 
 // RegisterDialect 注册一个数据库方言
@@ -0,0 +1,48 @@
+package dialect
+
+import "database/sql"
+
+// Queryer 是执行查询的接口，*sql.DB 和 *sql.Tx 都实现了该接口，用于在事务中读取表结构
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Column 表示数据库中表的一列
+type Column struct {
+	Name       string // 列名
+	Type       string // 声明的数据类型，例如 integer
+	NotNull    bool   // 是否有 NOT NULL 约束
+	Default    string // 默认值表达式，例如 10、'Tom'、CURRENT_TIMESTAMP，没有默认值时为空
+	PrimaryKey bool   // 是否是主键的一部分
+	Generated  string // 生成列的表达式，不是生成列时为空
+	Stored     bool   // 生成列是否是 STORED
+}
+
+// Index 表示数据库中表上的一个索引
+type Index struct {
+	Name    string   // 索引名
+	Columns []string // 按顺序排列的索引列，表达式列为空字符串
+	Unique  bool     // 是否是唯一索引
+	Origin  string   // 索引的来源：c 为 CREATE INDEX 创建，u 为 UNIQUE 约束，pk 为 PRIMARY KEY 约束
+	SQL     string   // 创建索引的语句，约束自动创建的索引为空
+}
+
+// 约束的类型
+const (
+	PrimaryKeyConstraint = "PRIMARY KEY"
+	UniqueConstraint     = "UNIQUE"
+	ForeignKeyConstraint = "FOREIGN KEY"
+	CheckConstraint      = "CHECK"
+)
+
+// Constraint 表示数据库中表上的一个约束
+type Constraint struct {
+	Name       string   // 约束名，数据库没有记录时为空
+	Type       string   // 约束的类型，例如 PrimaryKeyConstraint
+	Columns    []string // 约束涉及的列
+	RefTable   string   // 外键引用的表
+	RefColumns []string // 外键引用的列
+	OnDelete   string   // 外键的 ON DELETE 动作，例如 CASCADE
+	OnUpdate   string   // 外键的 ON UPDATE 动作
+	Expression string   // CHECK 约束的表达式
+}
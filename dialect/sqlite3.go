@@ -1,16 +1,24 @@
 package dialect
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // sqlite3 是一个实现了 Dialect 接口的结构体，用于处理 SQLite3 数据库的方言
-type sqlite3 struct{}
+type sqlite3 struct {
+	version [3]int // 通过 Detect 读取的 SQLite 版本，例如 {3, 45, 1}，没有读取时为零值
+}
 
-// 类型断言，确保 sqlite3 实现了 Dialect 接口
-var _ Dialect = (*sqlite3)(nil)
+// 类型断言，确保 sqlite3 实现了 Dialect 和 Detector 接口
+var (
+	_ Dialect  = (*sqlite3)(nil)
+	_ Detector = (*sqlite3)(nil)
+)
 
 // init 函数在包被导入时自动执行，用于注册 sqlite3 数据库方言
 func init() {
@@ -24,7 +32,19 @@ func init() {
 //
 // 返回值:
 // string: 数据库中的数据类型
+//
+// 指针使用其指向的类型，表示可以为 NULL 的列；sql.NullString 等 sql.Null* 类型使用其值字段的类型，
+// 其他实现了 driver.Valuer 的类型无法确定写入的值的类型，使用没有类型亲和性的 blob
 func (s *sqlite3) DataTypeOf(typ reflect.Value) string {
+	if typ.Kind() == reflect.Ptr {
+		return s.DataTypeOf(reflect.New(typ.Type().Elem()).Elem())
+	}
+	if isValuer(typ.Type()) {
+		if value, ok := nullValue(typ.Type()); ok {
+			return s.DataTypeOf(reflect.New(value).Elem())
+		}
+		return "blob"
+	}
 	switch typ.Kind() {
 	case reflect.Bool:
 		return "bool"
@@ -49,6 +69,65 @@ func (s *sqlite3) DataTypeOf(typ reflect.Value) string {
 	panic(fmt.Sprintf("invalid sql type %s (%s)", typ.Type().Name(), typ.Kind()))
 }
 
+// GoTypeOf 按 SQLite 的类型亲和性规则返回列 column 对应的 Go 语言类型
+//
+// 参数:
+// column: 表中的列
+//
+// 返回值:
+// reflect.Type: DataTypeOf 生成的类型映射回原来的 Go 类型，例如 integer 为 int、bigint 为 int64；
+// 其他声明的类型按亲和性映射，例如 VARCHAR(255) 为 string、DECIMAL(10,2) 为 float64，没有声明类型时为 []byte
+func (s *sqlite3) GoTypeOf(column Column) reflect.Type {
+	typ := strings.ToUpper(strings.TrimSpace(column.Type))
+	switch typ {
+	case "INTEGER", "INT":
+		return reflect.TypeOf(0)
+	case "BIGINT":
+		return reflect.TypeOf(int64(0))
+	case "BOOL", "BOOLEAN":
+		return reflect.TypeOf(false)
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return reflect.TypeOf(time.Time{})
+	}
+	switch {
+	case strings.Contains(typ, "INT"):
+		return reflect.TypeOf(int64(0))
+	case strings.Contains(typ, "CHAR"), strings.Contains(typ, "CLOB"), strings.Contains(typ, "TEXT"):
+		return reflect.TypeOf("")
+	case typ == "" || strings.Contains(typ, "BLOB"):
+		return reflect.TypeOf([]byte(nil))
+	case strings.Contains(typ, "REAL"), strings.Contains(typ, "FLOA"), strings.Contains(typ, "DOUB"):
+		return reflect.TypeOf(float64(0))
+	case strings.Contains(typ, "DATE"), strings.Contains(typ, "TIME"):
+		return reflect.TypeOf(time.Time{})
+	}
+	// 其余类型为 NUMERIC 亲和性，例如 NUMERIC、DECIMAL(10,2)
+	return reflect.TypeOf(float64(0))
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// isValuer 判断类型 typ 或者它的指针是否实现了 driver.Valuer
+func isValuer(typ reflect.Type) bool {
+	return typ.Implements(valuerType) || reflect.PointerTo(typ).Implements(valuerType)
+}
+
+// nullValue 判断 typ 是否是 sql.NullString、sql.Null[T] 这样由值字段和 Valid 字段组成的结构体，是则返回值字段的类型
+func nullValue(typ reflect.Type) (reflect.Type, bool) {
+	if typ.Kind() != reflect.Struct || typ.NumField() != 2 {
+		return nil, false
+	}
+	if valid := typ.Field(1); valid.Name != "Valid" || valid.Type.Kind() != reflect.Bool {
+		return nil, false
+	}
+	return typ.Field(0).Type, true
+}
+
+// BindVar 返回 SQLite 中第 i 个参数的占位符，SQLite 支持 ? 占位符，不需要转换
+func (s *sqlite3) BindVar(i int) string {
+	return "?"
+}
+
 // TableExistSQL 生成检查 SQLite 数据库中某个表是否存在的 SQL 语句
 //
 // 参数:
@@ -63,3 +142,112 @@ func (s *sqlite3) TableExistSQL(tableName string) (string, []interface{}) {
 	// sqlite_master 表包含了数据库中的所有表、索引、视图和触发器的信息
 	return "SELECT name FROM sqlite_master WHERE type='table' and name = ?", args
 }
+
+// TableObjectsSQL 生成查询 SQLite 数据库中某个表上的索引和触发器定义语句的 SQL 语句
+//
+// 参数:
+// tableName: 表名
+//
+// 返回值:
+// string: SQL 查询语句，自动创建的索引（如 UNIQUE 约束的索引）没有定义语句，不包含在结果中
+// []interface{}: 查询参数
+func (s *sqlite3) TableObjectsSQL(tableName string) (string, []interface{}) {
+	args := []interface{}{tableName}
+	return "SELECT sql FROM sqlite_master WHERE type IN ('index', 'trigger') AND tbl_name = ? AND sql IS NOT NULL", args
+}
+
+// ForeignKeysSQL 返回在当前连接上开启或关闭 SQLite 外键约束检查的语句
+//
+// 参数:
+// enabled: 开启为 true，关闭为 false
+//
+// 返回值:
+// string: PRAGMA 语句，SQLite 默认不检查外键约束，需要在每个连接上开启；在事务中执行时没有效果
+func (s *sqlite3) ForeignKeysSQL(enabled bool) string {
+	if enabled {
+		return "PRAGMA foreign_keys = ON"
+	}
+	return "PRAGMA foreign_keys = OFF"
+}
+
+// ForeignKeysEnabledSQL 返回查询当前连接是否开启了 SQLite 外键约束检查的语句
+func (s *sqlite3) ForeignKeysEnabledSQL() string {
+	return "PRAGMA foreign_keys"
+}
+
+// ForeignKeyCheckSQL 返回查询 SQLite 数据库中违反外键约束的记录的语句
+func (s *sqlite3) ForeignKeyCheckSQL() string {
+	return "PRAGMA foreign_key_check"
+}
+
+// MaxPlaceholders 返回 SQLite 单条语句允许的最大占位符数量
+//
+// 返回值:
+// int: SQLITE_MAX_VARIABLE_NUMBER 的默认值，SQLite 3.32.0 起为 32766
+func (s *sqlite3) MaxPlaceholders() int {
+	return 32766
+}
+
+// Quote 使用双引号包裹标识符，标识符中的双引号会被转义
+//
+// 参数:
+// name: 标识符
+//
+// 返回值:
+// string: 加上引号的标识符，例如 Name => "Name"
+func (s *sqlite3) Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Supports 返回 SQLite 是否支持可选特性 feature
+//
+// 参数:
+// feature: 可选特性
+//
+// 返回值:
+// bool: SQLite 不支持行锁，它在事务中锁定整个数据库；SQLite 3.35.0 起支持 RETURNING 和 DROP COLUMN，
+// 没有通过 Detect 读取版本时视为不支持；DROP COLUMN 不能删除主键、UNIQUE、有索引或者被约束引用的列，这些列仍然通过重建表删除
+func (s *sqlite3) Supports(feature Feature) bool {
+	switch feature {
+	case Returning, DropColumn:
+		return s.atLeast(3, 35, 0)
+	}
+	return false
+}
+
+// Detect 通过 sqlite_version() 读取 SQLite 的版本
+//
+// 参数:
+// db: 数据库连接
+//
+// 返回值:
+// Dialect: 记录了该版本的 SQLite 方言
+// error: 如果查询失败或者版本号格式不正确，返回错误信息
+func (s *sqlite3) Detect(db Queryer) (Dialect, error) {
+	values, err := queryStrings(db, "SELECT sqlite_version()")
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("failed to read sqlite version")
+	}
+	d := &sqlite3{}
+	parts := strings.SplitN(values[0], ".", 3)
+	for i, part := range parts {
+		if d.version[i], err = strconv.Atoi(part); err != nil {
+			return nil, fmt.Errorf("invalid sqlite version %s", values[0])
+		}
+	}
+	return d, nil
+}
+
+// atLeast 判断读取的 SQLite 版本是否不低于 major.minor.patch
+func (s *sqlite3) atLeast(major, minor, patch int) bool {
+	want := [3]int{major, minor, patch}
+	for i := range want {
+		if s.version[i] != want[i] {
+			return s.version[i] > want[i]
+		}
+	}
+	return true
+}
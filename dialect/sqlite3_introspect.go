@@ -0,0 +1,388 @@
+package dialect
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// Tables 读取 SQLite 数据库中所有的表名
+//
+// 参数:
+// db: 执行查询的数据库连接或事务
+//
+// 返回值:
+// []string: 按名称排序的表名，不包括 sqlite_sequence 等以 sqlite_ 开头的内部表
+// error: 如果查询失败，返回错误信息
+func (s *sqlite3) Tables(db Queryer) ([]string, error) {
+	return queryStrings(db, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name`)
+}
+
+// ColumnTypes 通过 pragma_table_xinfo 读取 SQLite 表中各列的定义
+//
+// 参数:
+// db: 执行查询的数据库连接或事务
+// tableName: 表名
+//
+// 返回值:
+// []Column: 按表中顺序排列的列，包括生成列，表不存在时为空
+// error: 如果查询失败，返回错误信息
+//
+// SQLite 不记录生成列的表达式，需要从 sqlite_master 中创建表的语句解析
+func (s *sqlite3) ColumnTypes(db Queryer, tableName string) ([]Column, error) {
+	// hidden 为 1 是虚拟表的隐藏列，2 是 VIRTUAL 生成列，3 是 STORED 生成列
+	rows, err := db.Query(`SELECT name, type, "notnull", dflt_value, pk, hidden FROM pragma_table_xinfo(?)
+		WHERE hidden != 1 ORDER BY cid`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	var columns []Column
+	generated := false
+	for rows.Next() {
+		var c Column
+		var dflt sql.NullString
+		var pk, hidden int
+		if err = rows.Scan(&c.Name, &c.Type, &c.NotNull, &dflt, &pk, &hidden); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		c.Default, c.PrimaryKey, c.Stored = dflt.String, pk > 0, hidden == 3
+		generated = generated || hidden > 1
+		columns = append(columns, c)
+	}
+	if err = rows.Close(); err != nil || !generated {
+		return columns, err
+	}
+	definitions, err := tableDefinitions(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for i := range columns {
+		columns[i].Generated = generatedExpression(definitions[columns[i].Name])
+	}
+	return columns, nil
+}
+
+// Indexes 通过 pragma_index_list 和 pragma_index_info 读取 SQLite 表上的索引
+//
+// 参数:
+// db: 执行查询的数据库连接或事务
+// tableName: 表名
+//
+// 返回值:
+// []Index: 表上的索引，INTEGER PRIMARY KEY 是表的 rowid，没有对应的索引
+// error: 如果查询失败，返回错误信息
+func (s *sqlite3) Indexes(db Queryer, tableName string) ([]Index, error) {
+	rows, err := db.Query(`SELECT l.name, l."unique", l.origin, coalesce(m.sql, '') FROM pragma_index_list(?) AS l
+		LEFT JOIN sqlite_master AS m ON m.type = 'index' AND m.name = l.name ORDER BY l.name`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []Index
+	for rows.Next() {
+		var index Index
+		if err = rows.Scan(&index.Name, &index.Unique, &index.Origin, &index.SQL); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		index.SQL = strings.TrimSpace(index.SQL)
+		indexes = append(indexes, index)
+	}
+	// 在事务中只有一个连接，需要先关闭 rows 才能查询索引的列
+	if err = rows.Close(); err != nil {
+		return nil, err
+	}
+	for i := range indexes {
+		if indexes[i].Columns, err = queryStrings(db, `SELECT coalesce(name, '') FROM pragma_index_info(?) ORDER BY seqno`,
+			indexes[i].Name); err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
+
+// Constraints 读取 SQLite 表上的主键、唯一、外键和 CHECK 约束
+//
+// 参数:
+// db: 执行查询的数据库连接或事务
+// tableName: 表名
+//
+// 返回值:
+// []Constraint: 表上的约束，SQLite 不记录约束名，只有从创建表的语句中解析的 CHECK 约束有 Name
+// error: 如果查询失败，返回错误信息
+func (s *sqlite3) Constraints(db Queryer, tableName string) ([]Constraint, error) {
+	var constraints []Constraint
+	pk, err := queryStrings(db, `SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(pk) > 0 {
+		constraints = append(constraints, Constraint{Type: PrimaryKeyConstraint, Columns: pk})
+	}
+	indexes, err := s.Indexes(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indexes {
+		if index.Origin == "u" {
+			constraints = append(constraints, Constraint{Type: UniqueConstraint, Columns: index.Columns})
+		}
+	}
+	rows, err := db.Query(`SELECT id, "table", "from", coalesce("to", ''), on_update, on_delete
+		FROM pragma_foreign_key_list(?) ORDER BY id, seq`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	foreignKeys := make(map[int]*Constraint)
+	for rows.Next() {
+		var id int
+		var refTable, from, to, onUpdate, onDelete string
+		if err = rows.Scan(&id, &refTable, &from, &to, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		fk, ok := foreignKeys[id]
+		if !ok {
+			fk = &Constraint{Type: ForeignKeyConstraint, RefTable: refTable, OnDelete: onDelete, OnUpdate: onUpdate}
+			foreignKeys[id] = fk
+		}
+		fk.Columns = append(fk.Columns, from)
+		fk.RefColumns = append(fk.RefColumns, to)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(foreignKeys))
+	for id := range foreignKeys {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		constraints = append(constraints, *foreignKeys[id])
+	}
+	checks, err := checkConstraints(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return append(constraints, checks...), nil
+}
+
+// queryStrings 执行只有一列结果的查询，返回所有行的值
+func queryStrings(db Queryer, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var values []string
+	for rows.Next() {
+		var v string
+		if err = rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// tableConstraintKeywords 是表约束开头的关键字，其余的定义是列的定义
+var tableConstraintKeywords = []string{"CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN"}
+
+// tableSQL 返回 sqlite_master 中创建表 tableName 的语句，表不存在时为空
+func tableSQL(db Queryer, tableName string) (string, error) {
+	values, err := queryStrings(db, `SELECT coalesce(sql, '') FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName)
+	if err != nil || len(values) == 0 {
+		return "", err
+	}
+	return values[0], nil
+}
+
+// definitions 返回创建表的语句中括号内以逗号分隔的各项定义，每项定义拆分为词法单元
+func definitions(sql string) [][]string {
+	var body string
+	for _, token := range tokenize(sql) {
+		if strings.HasPrefix(token, "(") {
+			body = token[1 : len(token)-1]
+			break
+		}
+	}
+	var defs [][]string
+	var def []string
+	for _, token := range tokenize(body) {
+		if token == "," {
+			defs, def = append(defs, def), nil
+			continue
+		}
+		def = append(def, token)
+	}
+	if len(def) > 0 {
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// tableDefinitions 返回表 tableName 中列名到列定义的映射
+func tableDefinitions(db Queryer, tableName string) (map[string][]string, error) {
+	sql, err := tableSQL(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string][]string)
+	for _, def := range definitions(sql) {
+		if !isTableConstraint(def) {
+			columns[unquoteIdentifier(def[0])] = def
+		}
+	}
+	return columns, nil
+}
+
+// checkConstraints 从创建表的语句中解析表 tableName 上的 CHECK 约束，列的 CHECK 约束的 Columns 为该列
+func checkConstraints(db Queryer, tableName string) ([]Constraint, error) {
+	sql, err := tableSQL(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	var checks []Constraint
+	for _, def := range definitions(sql) {
+		var columns []string
+		if !isTableConstraint(def) {
+			columns = []string{unquoteIdentifier(def[0])}
+		}
+		for i := 1; i < len(def); i++ {
+			if !strings.EqualFold(def[i-1], "CHECK") || !strings.HasPrefix(def[i], "(") {
+				continue
+			}
+			check := Constraint{Type: CheckConstraint, Columns: columns, Expression: groupContent(def[i])}
+			if i >= 3 && strings.EqualFold(def[i-3], "CONSTRAINT") {
+				check.Name = unquoteIdentifier(def[i-2])
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+// generatedExpression 返回列定义 def 中 [GENERATED ALWAYS] AS (expr) 的表达式，不是生成列时为空
+func generatedExpression(def []string) string {
+	for i := 1; i < len(def); i++ {
+		if strings.EqualFold(def[i-1], "AS") && strings.HasPrefix(def[i], "(") {
+			return groupContent(def[i])
+		}
+	}
+	return ""
+}
+
+// isTableConstraint 判断定义 def 是否是表约束
+func isTableConstraint(def []string) bool {
+	if len(def) == 0 {
+		return true
+	}
+	for _, keyword := range tableConstraintKeywords {
+		if strings.EqualFold(def[0], keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenize 将 SQL 拆分为词法单元：词、引号包裹的字符串或标识符、括号包裹的整个部分以及单个标点，忽略空白和注释
+func tokenize(sql string) []string {
+	var tokens []string
+	for i := 0; i < len(sql); {
+		start := i
+		switch c := sql[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(sql[i:], "--"):
+			i = tokenEnd(sql, i+2, "\n")
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = tokenEnd(sql, i+2, "*/")
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			i = quoteEnd(sql, i)
+		case c == '[':
+			i = tokenEnd(sql, i+1, "]")
+		case c == '(':
+			i = groupEnd(sql, i)
+		case isWordByte(c):
+			for i < len(sql) && isWordByte(sql[i]) {
+				i++
+			}
+		default:
+			i++
+		}
+		tokens = append(tokens, sql[start:i])
+	}
+	return tokens
+}
+
+// tokenEnd 返回从 i 开始第一个 end 之后的位置，没有找到时返回 len(sql)
+func tokenEnd(sql string, i int, end string) int {
+	if j := strings.Index(sql[i:], end); j >= 0 {
+		return i + j + len(end)
+	}
+	return len(sql)
+}
+
+// quoteEnd 返回从 i 处的引号开始的字符串或标识符结束后的位置，连续的两个引号表示转义
+func quoteEnd(sql string, i int) int {
+	quote := sql[i]
+	for i++; i < len(sql); i++ {
+		if sql[i] != quote {
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(sql)
+}
+
+// groupEnd 返回从 i 处的左括号开始到对应的右括号之后的位置，忽略引号中的括号
+func groupEnd(sql string, i int) int {
+	depth := 0
+	for i < len(sql) {
+		switch sql[i] {
+		case '\'', '"', '`':
+			i = quoteEnd(sql, i)
+			continue
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(sql)
+}
+
+// groupContent 返回括号包裹的部分中去掉括号和首尾空白的内容
+func groupContent(group string) string {
+	return strings.TrimSpace(strings.TrimSuffix(group[1:], ")"))
+}
+
+// isWordByte 判断 c 是否可以是词的一部分
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// unquoteIdentifier 去掉标识符两侧的引号或方括号
+func unquoteIdentifier(name string) string {
+	if len(name) >= 2 {
+		switch first, last := name[0], name[len(name)-1]; {
+		case first == '[' && last == ']':
+			return name[1 : len(name)-1]
+		case (first == '"' || first == '`' || first == '\'') && last == first:
+			q := string(first)
+			return strings.ReplaceAll(name[1:len(name)-1], q+q, q)
+		}
+	}
+	return name
+}
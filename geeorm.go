@@ -1,18 +1,22 @@
 package geeorm
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"geeorm/dialect"
 	"geeorm/log"
+	"geeorm/schema"
 	"geeorm/session"
 	"strings"
 )
 
 // Engine 是 GeeORM 的核心结构体，负责数据库连接管理和会话创建
 type Engine struct {
-	db      *sql.DB         // 数据库连接
-	dialect dialect.Dialect // 数据库方言
+	db               *sql.DB         // 数据库连接
+	dialect          dialect.Dialect // 数据库方言
+	allowDestructive bool            // 是否允许迁移删除模型中不存在的列
 }
 
 // NewEngine 创建一个新的 Engine 实例
@@ -24,8 +28,16 @@ type Engine struct {
 // 返回值:
 // *Engine: 返回创建的 Engine 实例
 // error: 如果创建过程中发生错误，返回错误信息
+//
+// 连接池中的每个连接都会开启外键约束检查，见 dialect.Dialect.ForeignKeysSQL
 func NewEngine(driverName, dataSourceName string) (e *Engine, err error) {
-	db, err := sql.Open(driverName, dataSourceName)
+	// 确保指定的数据库方言已注册
+	dial, ok := dialect.GetDialect(driverName)
+	if !ok {
+		log.Error("dialect %s Not Found", driverName)
+		return
+	}
+	db, err := openDB(driverName, dataSourceName, dial.ForeignKeysSQL(true))
 	if err != nil {
 		log.Error(err)
 		return
@@ -35,10 +47,9 @@ func NewEngine(driverName, dataSourceName string) (e *Engine, err error) {
 		log.Error(err)
 		return
 	}
-	// 确保指定的数据库方言已注册
-	dial, ok := dialect.GetDialect(driverName)
-	if !ok {
-		log.Error("dialect %s Not Found", driverName)
+	// 按数据库的版本判断方言支持的特性，例如 SQLite 3.35.0 起支持 RETURNING
+	if dial, err = dialect.Detect(dial, db); err != nil {
+		log.Error(err)
 		return
 	}
 	// 创建 Engine 实例并返回
@@ -47,6 +58,68 @@ func NewEngine(driverName, dataSourceName string) (e *Engine, err error) {
 	return
 }
 
+// openDB 打开数据库，连接池中每个新建立的连接都会先执行 init，例如开启 SQLite 的外键约束检查
+func openDB(driverName, dataSourceName, init string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil || init == "" {
+		return db, err
+	}
+	// sql.Open 不会建立连接，这里只用它找到已注册的驱动
+	d := db.Driver()
+	_ = db.Close()
+	var base driver.Connector = dsnConnector{driver: d, dsn: dataSourceName}
+	if dc, ok := d.(driver.DriverContext); ok {
+		if base, err = dc.OpenConnector(dataSourceName); err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(&initConnector{Connector: base, init: init}), nil
+}
+
+// dsnConnector 通过连接字符串建立连接，用于没有实现 driver.DriverContext 的驱动
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+// Connect 建立一个新的连接
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver 返回连接使用的驱动
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// initConnector 在每个新建立的连接上执行 init
+type initConnector struct {
+	driver.Connector
+	init string
+}
+
+// Connect 建立一个新的连接并执行 init，执行失败时关闭该连接
+func (c *initConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err = execer.ExecContext(ctx, c.init, nil)
+	} else {
+		var stmt driver.Stmt
+		if stmt, err = conn.Prepare(c.init); err == nil {
+			_, err = stmt.Exec(nil)
+			_ = stmt.Close()
+		}
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
 // Close 关闭数据库连接
 func (e *Engine) Close() {
 	if err := e.db.Close(); err != nil {
@@ -92,55 +165,149 @@ func (e *Engine) Transaction(f TxFunc) (result interface{}, err error) {
 	return f(s)
 }
 
-// difference 比较两个字符串切片的差异，返回 a - b 的结果
-func difference(a, b []string) (diff []string) {
-	m := make(map[string]bool)
-	for _, s := range b {
-		m[s] = true
-	}
-	for _, s := range a {
-		if _, ok := m[s]; !ok {
-			diff = append(diff, s)
+// AllowDestructive 返回一个允许迁移删除列的 Engine，它与 e 共用数据库连接
+//
+// 示例:
+// err := engine.AllowDestructive().Migrate(&User{})
+func (e *Engine) AllowDestructive() *Engine {
+	c := *e
+	c.allowDestructive = true
+	return &c
+}
+
+// Migrate 根据 value 的类型创建或修改表结构，见 session.Session.MigrateTable
+//
+// 表不存在时创建表；表存在时添加模型中新增的列，删除模型中不存在的列，
+// 并修改类型、NOT NULL、默认值或主键发生变化的列，修改时保留数据；
+// 删除列需要通过 AllowDestructive 明确允许，否则返回 session.ErrDestructiveMigration
+func (engine *Engine) Migrate(value interface{}) error {
+	// 在关闭了外键约束检查的事务中修改表结构
+	return engine.NewSession().MigrateTx(func(s *session.Session) error {
+		if engine.allowDestructive {
+			s.AllowDestructive()
 		}
+		return s.Model(value).MigrateTable()
+	})
+}
+
+// AutoMigrate 在同一个事务中创建或修改 models 对应的表结构，见 Migrate
+//
+// 参数:
+// models: 要迁移的模型
+//
+// 返回值:
+// error: 如果模型之间存在循环的外键引用，或者迁移过程中发生错误，返回错误信息，此时所有修改被回滚
+//
+// 模型中 Tags []Tag 这样的切片类型的关联字段对应的中间表（见 schema.Schema 的 JoinTables）在同一个事务中创建，
+// 中间表排在它引用的两张表之后；与中间表同名的模型优先于自动生成的中间表
+//
+// 被其他模型通过外键引用的表先迁移，没有依赖关系的模型保持传入的顺序；
+// 引用了不在 models 中的表时，认为该表已经存在
+//
+// 示例:
+// err := engine.AutoMigrate(&User{}, &Order{}, &Tag{}) // User 中有 Tags []Tag 时同时创建中间表 UserTags
+func (engine *Engine) AutoMigrate(models ...interface{}) error {
+	sorted, err := engine.sortModels(models)
+	if err != nil {
+		return err
 	}
-	return
+	return engine.NewSession().MigrateTx(func(s *session.Session) error {
+		for _, model := range sorted {
+			if engine.allowDestructive {
+				s.AllowDestructive()
+			}
+			if err := s.Model(model).MigrateTable(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// Migrate 根据 value 的类型创建表结构
-func (engine *Engine) Migrate(value interface{}) error {
-	// 事务操作
-	_, err := engine.Transaction(func(s *session.Session) (result interface{}, err error) {
-		// 如果表不存在，则创建表
-		if !s.Model(value).HasTable() {
-			log.Infof("table %s doesn't exist", s.RefTable().Name)
-			return nil, s.CreateTable()
+// sortModels 按外键依赖关系对 models 及其中间表进行拓扑排序，被引用的模型排在前面
+//
+// 中间表以 *schema.Schema 的形式出现在结果中，可以直接传给 Session.Model
+func (engine *Engine) sortModels(models []interface{}) ([]interface{}, error) {
+	schemas := make(map[string]*schema.Schema, len(models))
+	values := make(map[string]interface{}, len(models))
+	var names []string
+	var joinTables []*schema.Schema
+	for _, model := range models {
+		table := schema.Parse(model, engine.dialect)
+		if _, ok := schemas[table.Name]; !ok {
+			names = append(names, table.Name)
+		}
+		schemas[table.Name], values[table.Name] = table, model
+		joinTables = append(joinTables, table.JoinTables...)
+	}
+	for _, table := range joinTables {
+		if _, ok := schemas[table.Name]; !ok {
+			names = append(names, table.Name)
+			schemas[table.Name], values[table.Name] = table, table
 		}
-		// 获取表结构
-		table := s.RefTable()
-		rows, _ := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 1", table.Name)).QueryRows()
-		columns, _ := rows.Columns()
-		addCols := difference(table.FieldNames, columns)
-		delCols := difference(columns, table.FieldNames)
-		log.Infof("added cols %v, deleted cols %v", addCols, delCols)
-		// 如果表存在，但字段不一致，则修改表结构
-		for _, col := range addCols {
-			f := table.GetField(col)
-			sqlStr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table.Name, f.Name, f.Type)
-			if _, err = s.Raw(sqlStr).Exec(); err != nil {
-				return
+	}
+	// state 记录模型的访问状态：1 为正在访问，2 为已经排序
+	state := make(map[string]int, len(names))
+	var sorted []interface{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("circular foreign key dependency: %s", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, dep := range schemas[name].Dependencies() {
+			if _, ok := schemas[dep]; ok {
+				if err := visit(dep, append(path, name)); err != nil {
+					return err
+				}
 			}
 		}
-		// 删除表中不存在的字段
-		if len(delCols) == 0 {
-			return
+		state[name] = 2
+		sorted = append(sorted, values[name])
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
 		}
-		tmp := "tmp_" + table.Name
-		fieldStr := strings.Join(table.FieldNames, ", ")
-		s.Raw(fmt.Sprintf("CREATE TABLE %s AS SELECT %s from %s;", tmp, fieldStr, table.Name))
-		s.Raw(fmt.Sprintf("DROP TABLE %s;", table.Name))
-		s.Raw(fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tmp, table.Name))
-		_, err = s.Exec()
-		return
-	})
-	return err
+	}
+	return sorted, nil
+}
+
+// MigrateDryRun 计算迁移 models 所需的 DDL 语句和表结构的差异，只读取表结构，不修改数据库
+//
+// 参数:
+// models: 要迁移的模型
+//
+// 返回值:
+// *session.MigrationPlan: 各个表的差异和按执行顺序排列的 DDL 语句，Destructive 报告是否会删除列
+// error: 如果读取表结构失败，返回错误信息
+//
+// 模型按 AutoMigrate 的顺序排列
+//
+// 示例:
+//
+//	plan, err := engine.MigrateDryRun(&User{}, &Order{})
+//	for _, stmt := range plan.Statements {
+//		fmt.Println(stmt)
+//	}
+func (engine *Engine) MigrateDryRun(models ...interface{}) (*session.MigrationPlan, error) {
+	sorted, err := engine.sortModels(models)
+	if err != nil {
+		return nil, err
+	}
+	plan := &session.MigrationPlan{}
+	s := engine.NewSession()
+	for _, model := range sorted {
+		p, err := s.Model(model).PlanMigration()
+		if err != nil {
+			return nil, err
+		}
+		plan.Diffs = append(plan.Diffs, p.Diffs...)
+		plan.Statements = append(plan.Statements, p.Statements...)
+	}
+	return plan, nil
 }
@@ -2,8 +2,11 @@ package geeorm
 
 import (
 	"errors"
+	"geeorm/dialect"
 	"geeorm/log"
 	"geeorm/session"
+	"reflect"
+	"strings"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -97,3 +100,203 @@ func transactionCommit(t *testing.T) {
 		t.Fatal("failed to commit")
 	}
 }
+
+func TestEngine_Migrate(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text PRIMARY KEY, XXX integer, Age integer);").Exec()
+	_, _ = s.Raw("CREATE INDEX idx_user_age ON User(Age);").Exec()
+	_, _ = s.Raw("CREATE INDEX idx_user_xxx ON User(XXX);").Exec()
+	_, _ = s.Raw("INSERT INTO User(`Name`, `XXX`, `Age`) values (?, ?, ?)", "Tom", 1, 18).Exec()
+	plan, err := engine.MigrateDryRun(&User{})
+	if err != nil || !plan.Destructive() || len(plan.Statements) != 5 ||
+		!reflect.DeepEqual(plan.Diffs[0].RemovedColumns, []string{"XXX"}) ||
+		!reflect.DeepEqual(plan.Diffs[0].RemovedIndexes, []string{"idx_user_xxx"}) {
+		t.Fatal("failed to plan migration", plan, err)
+	}
+	if err = engine.Migrate(&User{}); !errors.Is(err, session.ErrDestructiveMigration) {
+		t.Fatal("expect destructive migration to be refused", err)
+	}
+	if columns, _ := s.Model(&User{}).TableColumns(); len(columns) != 3 {
+		t.Fatal("dry run and refused migration should not change the table", columns)
+	}
+	if err = engine.AllowDestructive().Migrate(&User{}); err != nil {
+		t.Fatal("failed to migrate", err)
+	}
+
+	columns, _ := s.Model(&User{}).TableColumns()
+	if !reflect.DeepEqual(columns, []string{"Name", "Age"}) {
+		t.Fatal("failed to drop column", columns)
+	}
+	u := &User{}
+	if err = s.First(u); err != nil || u.Name != "Tom" || u.Age != 18 {
+		t.Fatal("failed to keep data", u, err)
+	}
+	var indexes []string
+	_ = s.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'User' AND sql IS NOT NULL").Scan(&indexes)
+	if !reflect.DeepEqual(indexes, []string{"idx_user_age"}) {
+		t.Fatal("failed to recreate index", indexes)
+	}
+	if _, err = s.Insert(&User{"Tom", 20}); err == nil {
+		t.Fatal("failed to keep primary key")
+	}
+}
+
+type Book struct {
+	ID     int64  `geeorm:"PRIMARY KEY"`
+	Author string `geeorm:"REFERENCES User(Name)"`
+}
+
+type BookTag struct {
+	BookID int64  `geeorm:"REFERENCES Book(ID)"`
+	Tag    string `geeorm:"REFERENCES Tag(Name)"`
+}
+
+type Tag struct {
+	Name string `geeorm:"PRIMARY KEY"`
+}
+
+func TestEngine_AutoMigrate(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	for _, model := range []interface{}{&BookTag{}, &Book{}, &Tag{}, &User{}} {
+		_ = s.Model(model).DropTable()
+	}
+	plan, err := engine.MigrateDryRun(&BookTag{}, &Book{}, &Tag{}, &User{})
+	if err != nil {
+		t.Fatal("failed to plan migration", err)
+	}
+	var tables []string
+	for _, diff := range plan.Diffs {
+		tables = append(tables, diff.Table)
+	}
+	if !reflect.DeepEqual(tables, []string{"User", "Book", "Tag", "BookTag"}) {
+		t.Fatal("failed to order models by dependencies", tables)
+	}
+	if err = engine.AutoMigrate(&BookTag{}, &Book{}, &Tag{}, &User{}); err != nil {
+		t.Fatal("failed to auto migrate", err)
+	}
+	if !s.Model(&BookTag{}).HasTable() || !s.Model(&User{}).HasTable() {
+		t.Fatal("failed to create tables")
+	}
+	if plan, _ = engine.MigrateDryRun(&BookTag{}, &Book{}, &Tag{}, &User{}); len(plan.Statements) != 0 {
+		t.Fatal("tables should match the models after migration", plan.Statements)
+	}
+}
+
+func TestEngine_AutoMigrateJoinTable(t *testing.T) {
+	type User struct {
+		Name string `geeorm:"PRIMARY KEY"`
+		Age  int
+		Tags []Tag
+	}
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS UserTags;").Exec()
+	plan, err := engine.MigrateDryRun(&User{}, &Tag{})
+	if err != nil {
+		t.Fatal("failed to plan migration", err)
+	}
+	var tables []string
+	for _, diff := range plan.Diffs {
+		tables = append(tables, diff.Table)
+	}
+	if !reflect.DeepEqual(tables, []string{"User", "Tag", "UserTags"}) {
+		t.Fatal("join table should be migrated after both tables", tables)
+	}
+	if err = engine.AutoMigrate(&User{}, &Tag{}); err != nil {
+		t.Fatal("failed to auto migrate", err)
+	}
+	join := s.Model(&User{}).RefTable().JoinTables[0]
+	columns, err := s.Model(join).ColumnTypes()
+	if err != nil || len(columns) != 2 || columns[0].Name != "UserName" || columns[1].Name != "TagName" ||
+		!columns[0].PrimaryKey || !columns[1].PrimaryKey {
+		t.Fatal("failed to create join table", columns, err)
+	}
+	indexes, _ := s.Indexes()
+	unique := false
+	for _, index := range indexes {
+		unique = unique || index.Unique && reflect.DeepEqual(index.Columns, []string{"TagName", "UserName"})
+	}
+	if !unique {
+		t.Fatal("failed to create unique index on join table", indexes)
+	}
+	constraints, _ := s.Constraints()
+	var refs []string
+	for _, c := range constraints {
+		if c.Type == dialect.ForeignKeyConstraint {
+			refs = append(refs, c.RefTable+"("+strings.Join(c.RefColumns, ",")+")")
+		}
+	}
+	if !reflect.DeepEqual(refs, []string{"User(Name)", "Tag(Name)"}) && !reflect.DeepEqual(refs, []string{"Tag(Name)", "User(Name)"}) {
+		t.Fatal("failed to create foreign keys on join table", refs)
+	}
+	if plan, _ = engine.MigrateDryRun(&User{}, &Tag{}); len(plan.Statements) != 0 {
+		t.Fatal("join table should match after migration", plan.Statements)
+	}
+}
+
+type Left struct {
+	Right string `geeorm:"REFERENCES Right(Left)"`
+}
+
+type Right struct {
+	Left string `geeorm:"REFERENCES Left(Right)"`
+}
+
+func TestEngine_AutoMigrateCycle(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	if err := engine.AutoMigrate(&Left{}, &Right{}); err == nil {
+		t.Fatal("expect error for circular dependency")
+	}
+}
+
+type Post struct {
+	ID         int64 `geeorm:"PRIMARY KEY"`
+	AuthorName string
+	Author     *User `geeorm:"foreignKey:AuthorName;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+}
+
+func TestEngine_ForeignKey(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	for _, model := range []interface{}{&Post{}, &BookTag{}, &Book{}, &User{}} {
+		_ = s.Model(model).DropTable()
+	}
+	if err := engine.AutoMigrate(&Post{}, &User{}); err != nil {
+		t.Fatal("failed to auto migrate", err)
+	}
+	if plan, _ := engine.MigrateDryRun(&Post{}, &User{}); len(plan.Statements) != 0 {
+		t.Fatal("foreign keys should match the models after migration", plan.Diffs[0])
+	}
+	if _, err := s.Insert(&Post{ID: 1, AuthorName: "Tom"}); err == nil {
+		t.Fatal("failed to enforce foreign key")
+	}
+	_, _ = s.Insert(&User{"Tom", 18}, &User{"Sam", 20})
+	_, _ = s.Insert(&Post{ID: 1, AuthorName: "Tom"}, &Post{ID: 2, AuthorName: "Sam"})
+
+	// 重建被引用的表不会触发 ON DELETE CASCADE
+	type User struct {
+		Name  string `geeorm:"PRIMARY KEY"`
+		Age   int
+		Email string `geeorm:"UNIQUE"`
+	}
+	if err := engine.Migrate(&User{}); err != nil {
+		t.Fatal("failed to rebuild referenced table", err)
+	}
+	if n, _ := s.Model(&Post{}).Count(); n != 2 {
+		t.Fatal("rebuilding referenced table should keep referencing rows", n)
+	}
+	if _, err := s.Model(&User{}).Where("Name = ?", "Tom").Delete(); err != nil {
+		t.Fatal("failed to delete", err)
+	}
+	if n, _ := s.Model(&Post{}).Count(); n != 1 {
+		t.Fatal("failed to cascade delete", n)
+	}
+}
@@ -0,0 +1,245 @@
+// migrate 用于实现版本化的数据库迁移，已执行的迁移记录在 geeorm_migrations 表中
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"geeorm"
+	"geeorm/log"
+	"geeorm/session"
+	"sort"
+	"time"
+)
+
+// HistoryTable 是记录已执行迁移的表名
+const HistoryTable = "geeorm_migrations"
+
+// MigrateFunc 是迁移函数，在事务中执行，参数为事务所在的会话
+type MigrateFunc func(s *session.Session) error
+
+// Migration 表示一个版本化的迁移
+type Migration struct {
+	Version int64       // 版本号，按从小到大的顺序执行，不能重复
+	Name    string      // 迁移名称，例如 create_user
+	Up      MigrateFunc // 执行迁移
+	Down    MigrateFunc // 撤销迁移，为 nil 时该迁移不能撤销
+}
+
+// Status 表示一个迁移的执行状态
+type Status struct {
+	Version   int64     // 版本号
+	Name      string    // 迁移名称
+	Applied   bool      // 是否已执行
+	AppliedAt time.Time // 执行时间，未执行时为零值
+	Missing   bool      // 已执行但没有注册，通常是迁移被删除或者来自其他分支
+}
+
+// Migrator 负责按版本执行和撤销迁移
+type Migrator struct {
+	engine     *geeorm.Engine
+	migrations []*Migration // 按版本号排序的迁移
+}
+
+// New 创建一个新的 Migrator 实例
+//
+// 参数:
+// engine: 数据库引擎
+// migrations: 要管理的迁移，不要求有序
+//
+// 返回值:
+// *Migrator: 返回创建的 Migrator 实例
+// error: 如果迁移的版本号重复，或者没有 Up 函数，返回错误信息
+//
+// 示例:
+//
+//	m, err := migrate.New(engine, &migrate.Migration{
+//		Version: 1,
+//		Name:    "create_user",
+//		Up:      func(s *session.Session) error { return s.Model(&User{}).CreateTable() },
+//		Down:    func(s *session.Session) error { return s.Model(&User{}).DropTable() },
+//	})
+//	err = m.Up()
+func New(engine *geeorm.Engine, migrations ...*Migration) (*Migrator, error) {
+	sorted := append([]*Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d %s has no Up function", m.Version, m.Name)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+	}
+	return &Migrator{engine: engine, migrations: sorted}, nil
+}
+
+// Up 按版本号顺序执行所有未执行的迁移，每个迁移在单独的事务中执行，见 session.Session.MigrateTx
+//
+// 返回值:
+// error: 如果某个迁移执行失败，返回错误信息，该迁移被回滚，之前的迁移保持已执行
+func (m *Migrator) Up() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err = m.run(migration, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down 按版本号从大到小撤销最近执行的 n 个迁移，每个迁移在单独的事务中撤销
+//
+// 参数:
+// n: 要撤销的迁移数量，超过已执行的数量时撤销所有迁移
+//
+// 返回值:
+// error: 如果某个迁移没有注册、没有 Down 函数或者撤销失败，返回错误信息
+func (m *Migrator) Down(n int) error {
+	versions, err := m.appliedVersions()
+	if err != nil {
+		return err
+	}
+	for i := len(versions) - 1; i >= 0 && n > 0; i, n = i-1, n-1 {
+		migration := m.find(versions[i])
+		if migration == nil {
+			return fmt.Errorf("migration %d is applied but not registered", versions[i])
+		}
+		if err = m.run(migration, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redo 撤销并重新执行最近执行的迁移
+//
+// 返回值:
+// error: 如果没有已执行的迁移，或者撤销、执行失败，返回错误信息
+func (m *Migrator) Redo() error {
+	versions, err := m.appliedVersions()
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return errors.New("no migration to redo")
+	}
+	migration := m.find(versions[len(versions)-1])
+	if migration == nil {
+		return fmt.Errorf("migration %d is applied but not registered", versions[len(versions)-1])
+	}
+	if err = m.run(migration, false); err != nil {
+		return err
+	}
+	return m.run(migration, true)
+}
+
+// Status 返回所有迁移的执行状态，按版本号排序
+//
+// 返回值:
+// []Status: 已注册的迁移，以及已执行但没有注册的迁移
+// error: 如果读取迁移记录失败，返回错误信息
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var statuses []Status
+	for _, migration := range m.migrations {
+		st := Status{Version: migration.Version, Name: migration.Name}
+		if h, ok := applied[migration.Version]; ok {
+			st.Applied, st.AppliedAt = true, h.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, st)
+	}
+	for _, h := range applied {
+		statuses = append(statuses, Status{Version: h.Version, Name: h.Name, Applied: true, AppliedAt: h.AppliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// run 在事务中执行或撤销 migration，并更新迁移记录
+func (m *Migrator) run(migration *Migration, up bool) error {
+	f, action := migration.Up, "up"
+	if !up {
+		if migration.Down == nil {
+			return fmt.Errorf("migration %d %s has no Down function", migration.Version, migration.Name)
+		}
+		f, action = migration.Down, "down"
+	}
+	log.Infof("migrate %s %d %s", action, migration.Version, migration.Name)
+	// 与 Engine.Migrate 相同，在关闭了外键约束检查的事务中执行，避免重建表时触发外键动作
+	err := m.engine.NewSession().MigrateTx(func(s *session.Session) error {
+		if err := f(s); err != nil {
+			return err
+		}
+		var err error
+		if up {
+			_, err = s.Raw(fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", HistoryTable),
+				migration.Version, migration.Name, time.Now()).Exec()
+		} else {
+			_, err = s.Raw(fmt.Sprintf("DELETE FROM %s WHERE version = ?", HistoryTable), migration.Version).Exec()
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migrate %s %d %s: %w", action, migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// find 返回版本号为 version 的迁移，没有注册时返回 nil
+func (m *Migrator) find(version int64) *Migration {
+	i := sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].Version >= version })
+	if i < len(m.migrations) && m.migrations[i].Version == version {
+		return m.migrations[i]
+	}
+	return nil
+}
+
+// appliedVersions 返回已执行迁移的版本号，从小到大排序
+func (m *Migrator) appliedVersions() ([]int64, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// history 是迁移记录表中的一行
+type history struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+// applied 读取迁移记录，返回版本号到记录的映射，迁移记录表不存在时先创建
+func (m *Migrator) applied() (map[int64]history, error) {
+	s := m.engine.NewSession()
+	_, err := s.Raw(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version bigint PRIMARY KEY, name text, applied_at datetime)",
+		HistoryTable)).Exec()
+	if err != nil {
+		return nil, err
+	}
+	var rows []history
+	if err = s.Raw(fmt.Sprintf("SELECT version, name, applied_at AS AppliedAt FROM %s", HistoryTable)).Scan(&rows); err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]history, len(rows))
+	for _, h := range rows {
+		applied[h.Version] = h
+	}
+	return applied, nil
+}
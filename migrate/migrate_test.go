@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"errors"
+	"geeorm"
+	"geeorm/log"
+	"geeorm/session"
+	"os"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestMain(m *testing.M) {
+	log.SetLevel(log.ErrorLevel)
+	os.Exit(m.Run())
+}
+
+type Account struct {
+	Name string `geeorm:"PRIMARY KEY"`
+}
+
+type Invoice struct {
+	ID      int64 `geeorm:"PRIMARY KEY"`
+	Account string
+}
+
+// openEngine 打开测试数据库，并删除之前测试留下的表
+func openEngine(t *testing.T) *geeorm.Engine {
+	t.Helper()
+	engine, err := geeorm.NewEngine("sqlite3", "../gee.db")
+	if err != nil {
+		t.Fatal("failed to connect", err)
+	}
+	t.Cleanup(engine.Close)
+	s := engine.NewSession()
+	_ = s.Model(&Account{}).DropTable()
+	_ = s.Model(&Invoice{}).DropTable()
+	_, _ = s.Raw("DROP TABLE IF EXISTS " + HistoryTable).Exec()
+	return engine
+}
+
+func testMigrations() []*Migration {
+	return []*Migration{
+		{
+			Version: 2,
+			Name:    "create_invoice",
+			Up:      func(s *session.Session) error { return s.Model(&Invoice{}).CreateTable() },
+			Down:    func(s *session.Session) error { return s.Model(&Invoice{}).DropTable() },
+		},
+		{
+			Version: 1,
+			Name:    "create_account",
+			Up:      func(s *session.Session) error { return s.Model(&Account{}).CreateTable() },
+			Down:    func(s *session.Session) error { return s.Model(&Account{}).DropTable() },
+		},
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	engine := openEngine(t)
+	m, err := New(engine, testMigrations()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Up(); err != nil {
+		t.Fatal("failed to migrate up", err)
+	}
+	statuses, err := m.Status()
+	if err != nil || len(statuses) != 2 || !statuses[0].Applied || !statuses[1].Applied ||
+		statuses[0].Name != "create_account" || statuses[1].AppliedAt.IsZero() {
+		t.Fatal("failed to get status", statuses, err)
+	}
+	if err = m.Redo(); err != nil || !engine.NewSession().Model(&Invoice{}).HasTable() {
+		t.Fatal("failed to redo", err)
+	}
+	if err = m.Down(1); err != nil || engine.NewSession().Model(&Invoice{}).HasTable() {
+		t.Fatal("failed to migrate down", err)
+	}
+	if statuses, _ = m.Status(); !statuses[0].Applied || statuses[1].Applied {
+		t.Fatal("failed to record down", statuses)
+	}
+	if err = m.Down(5); err != nil || engine.NewSession().Model(&Account{}).HasTable() {
+		t.Fatal("failed to migrate all down", err)
+	}
+}
+
+func TestMigrator_Rollback(t *testing.T) {
+	engine := openEngine(t)
+	migrations := append(testMigrations(), &Migration{
+		Version: 3,
+		Name:    "broken",
+		Up: func(s *session.Session) error {
+			if _, err := s.Raw("INSERT INTO Account (Name) VALUES (?)", "Tom").Exec(); err != nil {
+				return err
+			}
+			return errors.New("broken")
+		},
+	})
+	m, _ := New(engine, migrations...)
+	if err := m.Up(); err == nil {
+		t.Fatal("expect error from broken migration")
+	}
+	statuses, _ := m.Status()
+	if !statuses[1].Applied || statuses[2].Applied {
+		t.Fatal("failed migration should not be recorded", statuses)
+	}
+	if n, _ := engine.NewSession().Model(&Account{}).Count(); n != 0 {
+		t.Fatal("failed migration should be rolled back", n)
+	}
+	if _, err := New(engine, append(migrations, &Migration{Version: 3, Up: migrations[0].Up})...); err == nil {
+		t.Fatal("expect error for duplicate version")
+	}
+}
@@ -0,0 +1,206 @@
+// 用于放置从 SQL 文件加载迁移相关的代码
+package migrate
+
+import (
+	"fmt"
+	"geeorm/session"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fileRegexp 匹配迁移文件名，例如 0001_create_user.up.sql
+var fileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// LoadFS 从 fsys 的根目录加载 SQL 文件形式的迁移
+//
+// 参数:
+// fsys: 包含迁移文件的文件系统，可以是 //go:embed 嵌入的 embed.FS，子目录可以通过 fs.Sub 获得
+//
+// 返回值:
+// []*Migration: 加载的迁移，可以与 Go 函数形式的迁移一起传给 New
+// error: 如果文件名不符合 NNNN_name.up.sql / NNNN_name.down.sql 的格式、版本号重复或者缺少 up 文件，返回错误信息
+//
+// 每个文件可以包含多条以分号分隔的语句，字符串、注释和 CREATE TRIGGER ... END 中的分号不会拆分语句；
+// 没有 down 文件的迁移不能撤销。
+//
+// 示例:
+//
+//	//go:embed migrations/*.sql
+//	var files embed.FS
+//
+//	sub, _ := fs.Sub(files, "migrations")
+//	migrations, err := migrate.LoadFS(sub)
+//	m, err := migrate.New(engine, migrations...)
+func LoadFS(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	var migrations []*Migration
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s, expect NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+			migrations = append(migrations, m)
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, m.Name, match[2])
+		}
+		f := execSQL(SplitStatements(string(data)))
+		if match[3] == "up" {
+			m.Up = f
+		} else {
+			m.Down = f
+		}
+	}
+	for _, m := range migrations {
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d %s has no up file", m.Version, m.Name)
+		}
+	}
+	return migrations, nil
+}
+
+// execSQL 返回依次执行 statements 的迁移函数
+func execSQL(statements []string) MigrateFunc {
+	return func(s *session.Session) error {
+		for _, stmt := range statements {
+			if _, err := s.Raw(stmt).Exec(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SplitStatements 将包含多条语句的 SQL 文本按分号拆分为单独的语句
+//
+// 参数:
+// sql: SQL 文本
+//
+// 返回值:
+// []string: 去掉首尾空白和结尾分号的语句，只包含注释的语句被丢弃
+//
+// 以下位置的分号不会拆分语句：
+//   - 单引号字符串、双引号和反引号标识符、Postgres 的 $tag$ 字符串
+//   - -- 行注释和 /* */ 块注释
+//   - CREATE TRIGGER 语句中 BEGIN 和 END 之间
+func SplitStatements(sql string) []string {
+	var statements []string
+	start := 0
+	// code 记录当前语句中去掉注释和字符串内容后的部分，用于识别 CREATE TRIGGER 和空语句
+	var code strings.Builder
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = closing(sql, i+1, string(c)) - 1
+			code.WriteString(string(c) + string(c))
+			continue
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			i = closing(sql, i+2, "\n") - 1
+			code.WriteByte(' ')
+			continue
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			i = closing(sql, i+2, "*/") - 1
+			code.WriteByte(' ')
+			continue
+		case c == '$':
+			if tag := dollarTag(sql[i:]); tag != "" {
+				i = closing(sql, i+len(tag), tag) - 1
+				code.WriteString("''")
+				continue
+			}
+		case c == ';':
+			upper := strings.ToUpper(code.String())
+			if isCreateTrigger(strings.Fields(upper)) && blockDepth(upper) > 0 {
+				// 触发器体中的语句以分号结尾，直到 BEGIN 对应的 END 才结束整个语句
+				code.WriteByte(c)
+				continue
+			}
+			if strings.TrimSpace(upper) != "" {
+				statements = append(statements, strings.TrimSpace(sql[start:i]))
+			}
+			start = i + 1
+			code.Reset()
+			continue
+		}
+		code.WriteByte(c)
+	}
+	if strings.TrimSpace(code.String()) != "" {
+		statements = append(statements, strings.TrimSpace(sql[start:]))
+	}
+	return statements
+}
+
+// closing 返回从 i 开始第一个 end 之后的位置，没有找到时返回 len(sql)
+//
+// 引号中连续的两个引号表示转义，不会结束字符串
+func closing(sql string, i int, end string) int {
+	for {
+		j := strings.Index(sql[i:], end)
+		if j < 0 {
+			return len(sql)
+		}
+		i += j + len(end)
+		if len(end) == 1 && end != "\n" && strings.HasPrefix(sql[i:], end) {
+			i++
+			continue
+		}
+		return i
+	}
+}
+
+// dollarTagRegexp 匹配 Postgres 的 $tag$ 字符串开头
+var dollarTagRegexp = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// dollarTag 返回 sql 开头的 $tag$，不是 $tag$ 字符串时返回空字符串
+func dollarTag(sql string) string {
+	return dollarTagRegexp.FindString(sql)
+}
+
+// blockRegexp 匹配开始和结束语句块的关键字，CASE 表达式同样以 END 结束
+var blockRegexp = regexp.MustCompile(`\b(BEGIN|CASE|END)\b`)
+
+// blockDepth 返回 upper 中尚未结束的 BEGIN 和 CASE 的数量
+func blockDepth(upper string) int {
+	depth := 0
+	for _, keyword := range blockRegexp.FindAllString(upper, -1) {
+		if keyword == "END" {
+			depth--
+		} else {
+			depth++
+		}
+	}
+	return depth
+}
+
+// isCreateTrigger 判断以 fields 开头的语句是否是 CREATE [TEMP|TEMPORARY] TRIGGER 语句
+func isCreateTrigger(fields []string) bool {
+	if len(fields) < 2 || fields[0] != "CREATE" {
+		return false
+	}
+	if fields[1] == "TEMP" || fields[1] == "TEMPORARY" {
+		fields = fields[1:]
+	}
+	return len(fields) > 1 && fields[1] == "TRIGGER"
+}
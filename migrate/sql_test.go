@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitStatements(t *testing.T) {
+	statements := SplitStatements(`
+-- create table; with comment
+CREATE TABLE Account (Name text DEFAULT 'a;b', "x;y" text); /* block; comment */
+CREATE TRIGGER t AFTER INSERT ON Account BEGIN
+	UPDATE Account SET Name = CASE WHEN Name = '' THEN 'none' ELSE Name END;
+	DELETE FROM Account WHERE Name = 'end;';
+END;
+-- only comment;
+INSERT INTO Account (Name) VALUES ('it''s; ok')`)
+	expect := []string{
+		`-- create table; with comment
+CREATE TABLE Account (Name text DEFAULT 'a;b', "x;y" text)`,
+		`/* block; comment */
+CREATE TRIGGER t AFTER INSERT ON Account BEGIN
+	UPDATE Account SET Name = CASE WHEN Name = '' THEN 'none' ELSE Name END;
+	DELETE FROM Account WHERE Name = 'end;';
+END`,
+		`-- only comment;
+INSERT INTO Account (Name) VALUES ('it''s; ok')`,
+	}
+	if !reflect.DeepEqual(statements, expect) {
+		t.Fatalf("failed to split statements: %q", statements)
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	engine := openEngine(t)
+	fsys := fstest.MapFS{
+		"0001_create_account.up.sql": {Data: []byte(
+			"CREATE TABLE Account (Name text PRIMARY KEY);\nINSERT INTO Account (Name) VALUES ('Tom');")},
+		"0001_create_account.down.sql": {Data: []byte("DROP TABLE Account;")},
+		"0002_add_sam.up.sql":          {Data: []byte("INSERT INTO Account (Name) VALUES ('Sam');")},
+		"README.md":                    {Data: []byte("not a migration")},
+	}
+	migrations, err := LoadFS(fsys)
+	if err != nil || len(migrations) != 2 || migrations[1].Name != "add_sam" || migrations[1].Down != nil {
+		t.Fatal("failed to load migrations", migrations, err)
+	}
+	m, _ := New(engine, migrations...)
+	if err = m.Up(); err != nil {
+		t.Fatal("failed to migrate up", err)
+	}
+	if n, _ := engine.NewSession().Model(&Account{}).Count(); n != 2 {
+		t.Fatal("expect 2 accounts, but got", n)
+	}
+	if err = m.Down(1); err == nil {
+		t.Fatal("expect error for migration without down file")
+	}
+
+	fsys["0003_broken.down.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	if _, err = LoadFS(fsys); err == nil {
+		t.Fatal("expect error for migration without up file")
+	}
+}
@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Check 表示标签中声明的 CHECK 约束
+type Check struct {
+	Name       string // 约束名
+	Expression string // 约束的表达式，例如 Age >= 0
+}
+
+// checkNameRegexp 匹配 check 选项中表达式之前的约束名，例如 chk_age,
+var checkNameRegexp = regexp.MustCompile(`^\s*(\w+)\s*,`)
+
+// parseChecks 解析各字段标签中的 check 选项，返回表上的 CHECK 约束
+//
+// 选项的格式为 check:[name,]expression，name 为约束名，默认为 chk_表名_列名，同一字段的多个约束依次加上序号；
+// 表达式可以引用表中的任意列
+//
+// 示例:
+//
+//	type User struct {
+//		Age   int    `geeorm:"check:Age >= 0"`
+//		Email string `geeorm:"check:chk_email,Email LIKE '%@%'"`
+//	}
+func parseChecks(schema *Schema) []*Check {
+	var checks []*Check
+	for _, field := range schema.Fields {
+		n := 0
+		for _, opt := range field.options {
+			if opt.Key != "check" || opt.Value == "" {
+				continue
+			}
+			check := &Check{Expression: opt.Value}
+			if match := checkNameRegexp.FindStringSubmatch(opt.Value); match != nil {
+				check.Name, check.Expression = match[1], strings.TrimSpace(opt.Value[len(match[0]):])
+			} else {
+				if check.Name = "chk_" + schema.Name + "_" + field.Name; n > 0 {
+					check.Name += "_" + strconv.Itoa(n+1)
+				}
+				n++
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// parseGenerated 解析标签中的 generated 选项
+//
+// 选项的格式为 generated:expression[,stored|,virtual]，默认为 VIRTUAL，即每次读取时计算，STORED 在写入时计算并保存
+func (f *Field) parseGenerated() {
+	value, ok := f.option("generated")
+	if !ok || value == "" {
+		return
+	}
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		switch strings.ToUpper(strings.TrimSpace(value[i+1:])) {
+		case "STORED":
+			value, f.Stored = value[:i], true
+		case "VIRTUAL":
+			value = value[:i]
+		}
+	}
+	f.Generated = strings.TrimSpace(value)
+}
@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ForeignKey 表示外键约束
+type ForeignKey struct {
+	Column    string // 当前表中的外键列
+	RefTable  string // 引用的表
+	RefColumn string // 引用的列，为空时引用主键
+	OnDelete  string // ON DELETE 动作，例如 CASCADE，为空时使用数据库的默认动作 NO ACTION
+	OnUpdate  string // ON UPDATE 动作
+	Inline    bool   // 是否在列的约束中通过 REFERENCES 声明，此时不需要单独的表约束
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// isAssociation 判断字段类型 typ 是否是关联的模型，即结构体或结构体指针，time.Time 和 driver.Valuer 除外
+func isAssociation(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && typ != timeType &&
+		!typ.Implements(valuerType) && !reflect.PointerTo(typ).Implements(valuerType)
+}
+
+var (
+	// referencesRegexp 匹配列的约束中的外键引用，例如 REFERENCES User(Name)
+	referencesRegexp = regexp.MustCompile(`(?i)\bREFERENCES\s+["'\x60]?(\w+)["'\x60]?\s*(?:\(\s*["'\x60]?(\w+)["'\x60]?\s*\))?`)
+	// actionRegexp 匹配外键的 ON DELETE 和 ON UPDATE 动作
+	actionRegexp = regexp.MustCompile(`(?i)\bON\s+(DELETE|UPDATE)\s+(SET\s+NULL|SET\s+DEFAULT|CASCADE|RESTRICT|NO\s+ACTION)`)
+)
+
+// parseForeignKeys 解析列的约束中的 REFERENCES 和关联模型的标签，返回外键约束
+//
+// 关联模型字段的标签格式为 foreignKey:列名;references:列名;constraint:OnDelete:动作,OnUpdate:动作：
+//   - foreignKey 为当前表中的外键列或者它的字段名，默认为字段名加 ID，默认的列不存在时不生成外键约束
+//   - references 为引用的列，默认为关联模型的主键列
+//   - constraint 为外键的 ON DELETE 和 ON UPDATE 动作
+//
+// 示例:
+//
+//	type Book struct {
+//		ID       int64 `geeorm:"PRIMARY KEY"`
+//		AuthorID string
+//		Author   *User `geeorm:"foreignKey:AuthorID;references:Name;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+//	}
+func parseForeignKeys(schema *Schema, associations []reflect.StructField) []*ForeignKey {
+	var foreignKeys []*ForeignKey
+	for _, field := range schema.Fields {
+		match := referencesRegexp.FindStringSubmatch(field.Tag)
+		if match == nil {
+			continue
+		}
+		fk := &ForeignKey{Column: field.Name, RefTable: match[1], RefColumn: match[2], Inline: true}
+		for _, action := range actionRegexp.FindAllStringSubmatch(field.Tag, -1) {
+			if strings.EqualFold(action[1], "DELETE") {
+				fk.OnDelete = normalizeAction(action[2])
+			} else {
+				fk.OnUpdate = normalizeAction(action[2])
+			}
+		}
+		foreignKeys = append(foreignKeys, fk)
+	}
+	for _, p := range associations {
+		typ := p.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		var tag Field
+		tag.parseTag(p.Tag.Get("geeorm"))
+		column, explicit := tag.option("foreignKey")
+		if !explicit {
+			column = p.Name + "ID"
+		}
+		field := schema.lookupField(column)
+		if field == nil {
+			if explicit {
+				panic(fmt.Sprintf("foreign key column %s of %s.%s not found", column, schema.Name, p.Name))
+			}
+			continue
+		}
+		fk := &ForeignKey{Column: field.Name, RefTable: tableName(typ), RefColumn: primaryKeyName(typ)}
+		if ref, ok := tag.option("references"); ok {
+			fk.RefColumn = ref
+		}
+		if constraint, ok := tag.option("constraint"); ok {
+			_, settings := parseSettings(","+constraint, "OnDelete", "OnUpdate")
+			fk.OnDelete, fk.OnUpdate = normalizeAction(settings["OnDelete"]), normalizeAction(settings["OnUpdate"])
+		}
+		foreignKeys = append(foreignKeys, fk)
+	}
+	return foreignKeys
+}
+
+// lookupField 根据列名或者结构体中的字段名获取 Field 对象，列名优先
+func (schema *Schema) lookupField(name string) *Field {
+	if field := schema.GetField(name); field != nil {
+		return field
+	}
+	for _, field := range schema.Fields {
+		if field.FieldName == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// primaryKeyName 返回结构体 typ 中标签声明了 PRIMARY KEY 的字段对应的列名，没有时返回空字符串
+func primaryKeyName(typ reflect.Type) string {
+	if field, ok := primaryKey(typ); ok {
+		return ColumnName(field)
+	}
+	return ""
+}
+
+// primaryKey 返回结构体 typ 中第一个标签声明了 PRIMARY KEY 的字段
+func primaryKey(typ reflect.Type) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		var f Field
+		f.parseTag(typ.Field(i).Tag.Get("geeorm"))
+		if f.PrimaryKey {
+			return typ.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// normalizeAction 将外键动作转换为大写并合并空白，例如 set  null => SET NULL
+func normalizeAction(action string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(action)), " ")
+}
+
+// Dependencies 返回表通过外键引用的其他表，按外键的顺序排列且不重复，不包含表自身
+func (schema *Schema) Dependencies() []string {
+	var deps []string
+	seen := map[string]bool{schema.Name: true}
+	for _, fk := range schema.ForeignKeys {
+		if !seen[fk.RefTable] {
+			seen[fk.RefTable] = true
+			deps = append(deps, fk.RefTable)
+		}
+	}
+	return deps
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Index 表示标签中声明的索引
+type Index struct {
+	Name    string   // 索引名
+	Unique  bool     // 是否是唯一索引
+	Columns []string // 按顺序排列的列名或表达式
+	Where   string   // 部分索引的条件，为空时索引所有行
+}
+
+// indexColumn 是索引中的一列，按 priority 从小到大排列，相同时按字段的顺序排列
+type indexColumn struct {
+	column   string
+	priority int
+}
+
+// parseIndexes 解析各字段标签中的 index 和 uniqueIndex 选项
+//
+// 选项的格式为 index[:name][,priority:n][,where:condition][,expression:expr]：
+//   - name 为索引名，默认为 idx_表名_列名，多个字段声明同名的索引时组成复合索引
+//   - priority 为列在复合索引中的顺序，默认为 10
+//   - where 为部分索引的条件
+//   - expression 为索引的表达式，代替字段对应的列
+//
+// 示例:
+//
+//	type User struct {
+//		Name  string `geeorm:"uniqueIndex:idx_name_age"`
+//		Age   int    `geeorm:"uniqueIndex:idx_name_age,priority:2;index:,where:Age > 0"`
+//		Email string `geeorm:"index:idx_email,expression:lower(Email)"`
+//	}
+func parseIndexes(schema *Schema) []*Index {
+	var indexes []*Index
+	byName := make(map[string]*Index)
+	columns := make(map[string][]indexColumn)
+	for _, field := range schema.Fields {
+		for _, opt := range field.options {
+			if opt.Key != "index" && opt.Key != "uniqueIndex" {
+				continue
+			}
+			name, settings := parseSettings(opt.Value, "priority", "where", "expression")
+			if name == "" {
+				name = "idx_" + schema.Name + "_" + field.Name
+			}
+			index, ok := byName[name]
+			if !ok {
+				index = &Index{Name: name}
+				byName[name] = index
+				indexes = append(indexes, index)
+			}
+			index.Unique = index.Unique || opt.Key == "uniqueIndex"
+			if where := settings["where"]; where != "" {
+				index.Where = where
+			}
+			column := indexColumn{column: field.Name, priority: 10}
+			if expr := settings["expression"]; expr != "" {
+				column.column = expr
+			}
+			if p, err := strconv.Atoi(settings["priority"]); err == nil {
+				column.priority = p
+			}
+			columns[name] = append(columns[name], column)
+		}
+	}
+	for _, index := range indexes {
+		cols := columns[index.Name]
+		sort.SliceStable(cols, func(i, j int) bool { return cols[i].priority < cols[j].priority })
+		for _, col := range cols {
+			index.Columns = append(index.Columns, col.column)
+		}
+	}
+	return indexes
+}
+
+// GetIndex 根据索引名获取标签中声明的索引，没有声明时返回 nil
+func (schema *Schema) GetIndex(name string) *Index {
+	for _, index := range schema.Indexes {
+		if index.Name == name {
+			return index
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package schema
+
+import (
+	"fmt"
+	"geeorm/dialect"
+	"reflect"
+)
+
+// parseJoinTables 根据切片类型的关联字段生成多对多关系的中间表
+//
+// 中间表名为表名加字段名，包含分别引用两张表主键的外键列，列名为表名加主键列名；
+// 两列组成复合主键，并以相反的顺序建立唯一索引，以便从关联的表反向查询；删除任意一方的记录时级联删除中间表中的记录。
+// 两张表都必须有主键，否则 panic
+//
+// 示例:
+//
+//	type User struct {
+//		Name string `geeorm:"PRIMARY KEY"`
+//		Tags []Tag
+//	}
+//
+// 生成中间表 UserTags(UserName, TagName)，主键为 (UserName, TagName)，唯一索引为 (TagName, UserName)
+func parseJoinTables(schema *Schema, slices []reflect.StructField, d dialect.Dialect) []*Schema {
+	var joinTables []*Schema
+	for _, p := range slices {
+		typ := p.Type.Elem()
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if schema.PrimaryField == nil {
+			panic(fmt.Sprintf("join table of %s.%s requires a primary key in %s", schema.Name, p.Name, schema.Name))
+		}
+		ref, ok := primaryKey(typ)
+		if !ok {
+			panic(fmt.Sprintf("join table of %s.%s requires a primary key in %s", schema.Name, p.Name, tableName(typ)))
+		}
+		refTable, refColumn := tableName(typ), ColumnName(ref)
+		owner := &Field{Name: schema.Name + schema.PrimaryField.Name, Type: schema.PrimaryField.Type}
+		other := &Field{Name: refTable + refColumn, Type: d.DataTypeOf(reflect.New(ref.Type).Elem())}
+		// 自关联时两列同名，引用关联表的列改用字段名，例如 Friends []User => UserName, FriendsName
+		if other.Name == owner.Name {
+			other.Name = p.Name + refColumn
+		}
+		join := &Schema{Name: schema.Name + p.Name, fieldMap: make(map[string]*Field)}
+		for _, field := range []*Field{owner, other} {
+			field.FieldName, field.Tag, field.NotNull, field.PrimaryKey = field.Name, "NOT NULL", true, true
+			join.Fields = append(join.Fields, field)
+			join.FieldNames = append(join.FieldNames, field.Name)
+			join.fieldMap[field.Name] = field
+		}
+		join.PrimaryKeys = []string{owner.Name, other.Name}
+		join.ForeignKeys = []*ForeignKey{
+			{Column: owner.Name, RefTable: schema.Name, RefColumn: schema.PrimaryField.Name, OnDelete: "CASCADE"},
+			{Column: other.Name, RefTable: refTable, RefColumn: refColumn, OnDelete: "CASCADE"},
+		}
+		join.Indexes = []*Index{{
+			Name:    "idx_" + join.Name + "_" + other.Name + "_" + owner.Name,
+			Unique:  true,
+			Columns: []string{other.Name, owner.Name},
+		}}
+		joinTables = append(joinTables, join)
+	}
+	return joinTables
+}
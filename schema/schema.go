@@ -5,22 +5,70 @@ import (
 	"geeorm/dialect"
 	"go/ast"
 	"reflect"
+	"strings"
 )
 
 // Field 表示数据库表的一列
 type Field struct {
-	Name string // 列名
-	Type string // 列的数据类型
-	Tag  string // 列的额外信息（标签）
+	Name       string // 列名
+	FieldName  string // 结构体中对应的字段名，标签中没有 column 选项时与列名相同
+	Type       string // 列的数据类型
+	Tag        string // 列的额外信息（标签）
+	NotNull    bool   // 标签中是否声明了 NOT NULL
+	Default    string // 标签中声明的默认值表达式，没有时为空
+	PrimaryKey bool   // 标签中是否声明了 PRIMARY KEY
+	Generated  string // 生成列的表达式，不是生成列时为空，生成列的值由数据库计算，不会被写入
+	Stored     bool   // 生成列是否是 STORED，否则为 VIRTUAL
+
+	options []tagOption // 标签中的选项，例如 index:idx_name
 }
 
 // Schema 表示数据库中的一张表
 type Schema struct {
-	Model      interface{}       // 表对应的对象
-	Name       string            // 表名
-	Fields     []*Field          // 表的所有列
-	FieldNames []string          // 表的所有列名
-	fieldMap   map[string]*Field // 列名到 Field 对象的映射
+	Model        interface{}       // 表对应的对象
+	Name         string            // 表名，默认为结构体的类型名，模型实现了 Tabler 时为 TableName 的返回值
+	Fields       []*Field          // 表的所有列
+	FieldNames   []string          // 表的所有列名
+	PrimaryField *Field            // 主键列，标签中包含 PRIMARY KEY 的列，没有则为 nil
+	PrimaryKeys  []string          // 复合主键的列，不为空时通过 PRIMARY KEY (...) 表约束声明，例如中间表的两个外键列
+	Indexes      []*Index          // 标签中声明的索引，按第一次声明的顺序排列
+	ForeignKeys  []*ForeignKey     // 外键约束，按字段的顺序排列
+	Checks       []*Check          // 标签中声明的 CHECK 约束，按字段的顺序排列
+	JoinTables   []*Schema         // 切片类型的关联字段（例如 Tags []Tag）对应的中间表，切片字段不是表中的列
+	fieldMap     map[string]*Field // 列名到 Field 对象的映射
+}
+
+// Tabler 是可选接口，模型实现该接口时使用 TableName 的返回值作为表名
+//
+// 示例:
+// func (OrderItem) TableName() string { return "order_items" }
+type Tabler interface {
+	TableName() string
+}
+
+// tableName 返回结构体类型 typ 对应的表名
+func tableName(typ reflect.Type) string {
+	if tabler, ok := reflect.New(typ).Interface().(Tabler); ok {
+		return tabler.TableName()
+	}
+	return typ.Name()
+}
+
+// ColumnName 返回结构体字段 field 对应的列名，即标签中 column 选项的值，没有时为字段名
+//
+// 示例:
+// CreatedAt time.Time `geeorm:"column:created_at"` => created_at
+func ColumnName(field reflect.StructField) string {
+	tag := field.Tag.Get("geeorm")
+	if !strings.Contains(strings.ToLower(tag), "column") {
+		return field.Name
+	}
+	var f Field
+	f.parseTag(tag)
+	if column, ok := f.option("column"); ok && column != "" {
+		return column
+	}
+	return field.Name
 }
 
 // GetField 根据列名获取 Field 对象
@@ -46,41 +94,69 @@ func Parse(dest interface{}, d dialect.Dialect) *Schema {
 	modelType := reflect.Indirect(reflect.ValueOf(dest)).Type()
 	schema := &Schema{
 		Model:    dest,
-		Name:     modelType.Name(),
+		Name:     tableName(modelType),
 		fieldMap: make(map[string]*Field),
 	}
+	var associations, slices []reflect.StructField
 	for i := 0; i < modelType.NumField(); i++ {
 		p := modelType.Field(i)
+		// 关联的模型不是表中的列，用于生成外键约束
+		if !p.Anonymous && ast.IsExported(p.Name) && isAssociation(p.Type) {
+			associations = append(associations, p)
+			continue
+		}
+		// 切片类型的关联字段通过中间表保存，不是表中的列
+		if !p.Anonymous && ast.IsExported(p.Name) && p.Type.Kind() == reflect.Slice && isAssociation(p.Type.Elem()) {
+			slices = append(slices, p)
+			continue
+		}
 		// 如果字段不是匿名字段且是导出字段，则创建 Field 对象
 		if !p.Anonymous && ast.IsExported(p.Name) {
 			field := &Field{
-				Name: p.Name,
-				Type: d.DataTypeOf(reflect.Indirect(reflect.New(p.Type))),
+				Name:      p.Name,
+				FieldName: p.Name,
+				Type:      d.DataTypeOf(reflect.Indirect(reflect.New(p.Type))),
 			}
-			// 如果字段有 geeorm 标签，则使用标签的值
+			// 如果字段有 geeorm 标签，则解析标签中的约束和选项
 			if v, ok := p.Tag.Lookup("geeorm"); ok {
-				field.Tag = v
+				field.parseTag(v)
+			}
+			// 标签的 column 选项声明了不同于字段名的列名
+			if column, ok := field.option("column"); ok && column != "" {
+				field.Name = column
+			}
+			// 第一个标签中声明了 PRIMARY KEY 的列作为主键
+			if schema.PrimaryField == nil && field.PrimaryKey {
+				schema.PrimaryField = field
 			}
 			schema.Fields = append(schema.Fields, field)
-			schema.FieldNames = append(schema.FieldNames, p.Name)
-			schema.fieldMap[p.Name] = field
+			schema.FieldNames = append(schema.FieldNames, field.Name)
+			schema.fieldMap[field.Name] = field
 		}
 	}
+	schema.Indexes = parseIndexes(schema)
+	schema.ForeignKeys = parseForeignKeys(schema, associations)
+	schema.Checks = parseChecks(schema)
+	schema.JoinTables = parseJoinTables(schema, slices, d)
 	return schema
 }
 
-// RecordValues 返回对象中所有列的值
+// RecordValues 返回对象中指定列的值
 //
 // 参数:
 // dest: 要获取值的对象
+// names: 要获取值的列名，为空时返回所有列的值
 //
 // 返回值:
-// []interface{}: 对象中所有列的值
-func (schema *Schema) RecordValues(dest interface{}) []interface{} {
+// []interface{}: 对象中对应列的值
+func (schema *Schema) RecordValues(dest interface{}, names ...string) []interface{} {
 	destvalue := reflect.Indirect(reflect.ValueOf(dest))
+	if len(names) == 0 {
+		names = schema.FieldNames
+	}
 	var fieldValues []interface{}
-	for _, field := range schema.Fields {
-		fieldValues = append(fieldValues, destvalue.FieldByName(field.Name).Interface())
+	for _, name := range names {
+		fieldValues = append(fieldValues, destvalue.FieldByName(schema.GetField(name).FieldName).Interface())
 	}
 	return fieldValues
 }
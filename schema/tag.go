@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tagOptionKeys 是标签中选项的名称，其余部分作为列定义中的约束，例如 NOT NULL
+var tagOptionKeys = []string{"index", "uniqueIndex", "foreignKey", "references", "constraint", "check", "generated", "column"}
+
+// tagOption 是标签中形如 key:value 的选项
+type tagOption struct {
+	Key   string
+	Value string
+}
+
+// defaultRegexp 匹配标签中的 DEFAULT 关键字，之后的默认值由 parseDefault 解析
+var defaultRegexp = regexp.MustCompile(`(?i)\bDEFAULT\s+`)
+
+// parseTag 解析 geeorm 标签，标签的各部分以分号分隔
+//
+// 以选项名称开头的部分作为选项，例如 index:idx_name,priority:2；其余部分以空格连接后作为列定义中的约束
+//
+// 示例:
+// `geeorm:"NOT NULL DEFAULT 0;index"` => Tag 为 NOT NULL DEFAULT 0，并声明一个索引
+func (f *Field) parseTag(tag string) {
+	var constraints []string
+	for _, part := range splitTag(tag) {
+		if opt, ok := parseOption(part); ok {
+			f.options = append(f.options, opt)
+		} else if part = strings.TrimSpace(part); part != "" {
+			constraints = append(constraints, part)
+		}
+	}
+	f.Tag = strings.Join(constraints, " ")
+	upper := strings.ToUpper(f.Tag)
+	f.NotNull = strings.Contains(upper, "NOT NULL")
+	f.PrimaryKey = strings.Contains(upper, "PRIMARY KEY")
+	if loc := defaultRegexp.FindStringIndex(f.Tag); loc != nil {
+		f.Default = parseDefault(f.Tag[loc[1]:])
+	}
+	f.parseGenerated()
+}
+
+// parseDefault 返回 s 开头的默认值，默认值可以是字符串、括号包裹的表达式或者单个词
+//
+// 括号包裹的表达式按括号配对，括号中的字符串可以包含括号
+//
+// 示例:
+// parseDefault("(datetime('now')) NOT NULL") => "(datetime('now'))"
+func parseDefault(s string) string {
+	if s == "" {
+		return ""
+	}
+	switch s[0] {
+	case '\'':
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return s[:i+1]
+		}
+		return s
+	case '(':
+		depth, quoted := 0, false
+		for i := 0; i < len(s); i++ {
+			switch {
+			case s[i] == '\'':
+				quoted = !quoted
+			case quoted:
+			case s[i] == '(':
+				depth++
+			case s[i] == ')':
+				if depth--; depth == 0 {
+					return s[:i+1]
+				}
+			}
+		}
+		return s
+	}
+	if i := strings.IndexAny(s, " \t\n,"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// splitTag 以单引号之外的分号拆分标签
+func splitTag(tag string) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i, c := range tag {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case c == ';' && !quoted:
+			parts = append(parts, tag[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tag[start:])
+}
+
+// parseOption 判断 part 是否是选项，是则返回解析后的选项
+func parseOption(part string) (tagOption, bool) {
+	part = strings.TrimSpace(part)
+	key, value, _ := strings.Cut(part, ":")
+	for _, name := range tagOptionKeys {
+		if strings.EqualFold(key, name) {
+			return tagOption{Key: name, Value: strings.TrimSpace(value)}, true
+		}
+	}
+	return tagOption{}, false
+}
+
+// option 返回名为 key 的第一个选项的值
+func (f *Field) option(key string) (string, bool) {
+	for _, opt := range f.options {
+		if opt.Key == key {
+			return opt.Value, true
+		}
+	}
+	return "", false
+}
+
+// parseSettings 解析选项值中以逗号分隔的设置，第一项为选项的参数，其余为 key:value 形式的设置
+//
+// 不以 keys 中的设置名开头的部分属于前一项设置，例如表达式中的逗号
+//
+// 示例:
+// parseSettings("idx_name,expression:substr(Name, 1, 2)", "expression") => "idx_name", {"expression": "substr(Name, 1, 2)"}
+func parseSettings(value string, keys ...string) (string, map[string]string) {
+	settings := make(map[string]string)
+	parts := strings.Split(value, ",")
+	arg, last := strings.TrimSpace(parts[0]), ""
+	for _, part := range parts[1:] {
+		key, v, _ := strings.Cut(part, ":")
+		key = strings.TrimSpace(key)
+		known := false
+		for _, k := range keys {
+			if strings.EqualFold(key, k) {
+				key, known = k, true
+				break
+			}
+		}
+		if known {
+			settings[key], last = strings.TrimSpace(v), key
+		} else if last != "" {
+			settings[last] += "," + part
+		} else {
+			arg += "," + part
+		}
+	}
+	return arg, settings
+}
@@ -0,0 +1,133 @@
+// 用于放置分批查询相关的代码
+package session
+
+import (
+	"errors"
+	"fmt"
+	"geeorm/clause"
+	"geeorm/schema"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// BatchFunc 是 FindInBatches 处理每一批记录的回调函数
+//
+// 参数:
+// tx: 处理该批记录所在的会话，开启 TxPerBatch 时是该批次独立的事务会话
+// batch: 批次序号，从 1 开始
+//
+// 返回值:
+// error: 返回错误时停止后续批次的处理
+type BatchFunc func(tx *Session, batch int) error
+
+// TxPerBatch 设置 FindInBatches 在独立的事务中处理每一批记录，返回值是 *Session 可以链式调用
+//
+// 如果当前会话已经处于事务中，则所有批次都在该事务中执行
+func (s *Session) TxPerBatch() *Session {
+	s.txPerBatch = true
+	return s
+}
+
+// FindInBatches 分批查找记录，每查到一批就填充到 values 中并调用 fn
+//
+// 参数:
+// values: 要填充的记录，每一批开始前都会被清空
+// batchSize: 每一批的记录数
+// fn: 处理每一批记录的回调函数
+//
+// 返回值:
+// error: 如果查找过程中或 fn 返回错误，返回错误信息
+//
+// 使用主键做键集分页（WHERE pk > ? ORDER BY pk LIMIT ?），而不是 OFFSET，
+// 因此表较大时每一批的查询代价不会随批次增加。当前的 WHERE 条件会保留到每一批查询中，
+// ORDER BY 和 LIMIT 会被主键分页覆盖。Select、Omit 和 Distinct 同样作用于每一批查询，
+// 主键列总会被查询。
+//
+// 示例:
+// var users []User
+//
+//	err := s.Where("Age > ?", 18).FindInBatches(&users, 100, func(tx *Session, batch int) error {
+//		// 处理 users
+//		return nil
+//	})
+func (s *Session) FindInBatches(values interface{}, batchSize int, fn BatchFunc) (err error) {
+	txPerBatch := s.txPerBatch
+	s.txPerBatch = false
+	if batchSize <= 0 {
+		s.Clear()
+		return errors.New("batch size must be positive")
+	}
+	destValue := reflect.Indirect(reflect.ValueOf(values))
+	destType := destValue.Type().Elem()
+	table := s.Model(reflect.New(destType).Elem().Interface()).RefTable()
+	if table.PrimaryField == nil {
+		s.Clear()
+		return fmt.Errorf("table %s has no primary key", table.Name)
+	}
+	// 保存当前的 WHERE 条件和查询的列，每一批查询都会带上它们
+	where, whereVars, err := s.clause.Build(clause.WHERE)
+	if err != nil {
+		s.Clear()
+		return err
+	}
+	where = strings.TrimPrefix(where, "WHERE ")
+	columns, err := s.columns(table)
+	if err != nil {
+		s.Clear()
+		return
+	}
+	if !slices.Contains(columns, table.PrimaryField.Name) {
+		columns = append(columns, table.PrimaryField.Name)
+	}
+	distinct := s.distinct
+	s.Clear()
+
+	var last interface{}
+	for batch := 1; ; batch++ {
+		var n int
+		run := func(bs *Session) error {
+			destValue.Set(reflect.MakeSlice(destValue.Type(), 0, batchSize))
+			if err := bs.findBatch(values, table, columns, distinct, where, whereVars, last, batchSize); err != nil {
+				return err
+			}
+			if n = destValue.Len(); n == 0 {
+				return nil
+			}
+			return fn(bs, batch)
+		}
+		if txPerBatch {
+			err = s.runInTx(run)
+		} else {
+			err = run(s)
+		}
+		if err != nil || n < batchSize {
+			return
+		}
+		last = destValue.Index(n - 1).FieldByName(table.PrimaryField.FieldName).Interface()
+	}
+}
+
+// findBatch 查找主键大于 last 的下一批记录，last 为 nil 时从第一条记录开始
+func (s *Session) findBatch(values interface{}, table *schema.Schema, columns []string, distinct bool,
+	where string, whereVars []interface{}, last interface{}, batchSize int) error {
+	pk := table.PrimaryField.Name
+	var conds []string
+	var vars []interface{}
+	if where != "" {
+		conds = append(conds, "("+where+")")
+		vars = append(vars, whereVars...)
+	}
+	if last != nil {
+		conds = append(conds, pk+" > ?")
+		vars = append(vars, last)
+	}
+	if len(conds) > 0 {
+		s.Where(strings.Join(conds, " AND "), vars...)
+	}
+	if distinct {
+		s.Distinct()
+	}
+	s.selects = columns
+	return s.OrderBy(pk).Limit(batchSize).Find(values)
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"errors"
+	"testing"
+)
+
+func testBatchInit(t *testing.T) *Session {
+	t.Helper()
+	s := NewSession().Model(&Account{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table", err)
+	}
+	for i := 1; i <= 10; i++ {
+		if _, err := s.Raw("INSERT INTO Account (ID, Password) VALUES (?, ?)", i, "pwd").Exec(); err != nil {
+			t.Fatal("failed to insert record", err)
+		}
+	}
+	return s
+}
+
+func TestSession_FindInBatches(t *testing.T) {
+	s := testBatchInit(t)
+	var accounts []Account
+	var ids []int
+	batches := 0
+	err := s.Where("ID <> ?", 5).FindInBatches(&accounts, 3, func(tx *Session, batch int) error {
+		batches = batch
+		for _, a := range accounts {
+			ids = append(ids, a.ID)
+		}
+		return nil
+	})
+	if err != nil || batches != 3 || len(ids) != 9 {
+		t.Fatal("failed to find in batches", err, batches, ids)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] || ids[i] == 5 {
+			t.Fatal("unexpected batch order", ids)
+		}
+	}
+}
+
+func TestSession_FindInBatchesStop(t *testing.T) {
+	s := testBatchInit(t)
+	var accounts []Account
+	stop := errors.New("stop")
+	batches := 0
+	err := s.FindInBatches(&accounts, 4, func(tx *Session, batch int) error {
+		batches = batch
+		return stop
+	})
+	if err != stop || batches != 1 {
+		t.Fatal("failed to stop on callback error", err, batches)
+	}
+}
+
+func TestSession_FindInBatchesTx(t *testing.T) {
+	s := testBatchInit(t)
+	var accounts []Account
+	err := s.TxPerBatch().FindInBatches(&accounts, 4, func(tx *Session, batch int) error {
+		if tx.tx == nil {
+			return errors.New("batch is not in transaction")
+		}
+		if _, err := tx.Raw("DELETE FROM Account WHERE ID = ?", accounts[0].ID).Exec(); err != nil {
+			return err
+		}
+		if batch == 3 {
+			return errors.New("rollback")
+		}
+		return nil
+	})
+	count, _ := s.Model(&Account{}).Count()
+	if err == nil || count != 8 {
+		t.Fatal("failed to run batches in transactions", err, count)
+	}
+}
@@ -29,7 +29,10 @@ const (
 //   - value: 可选的值，如果提供了该值，则从该值中查找方法。
 func (s *Session) CallMethod(method string, value interface{}) {
 	// 从 Session 的 Model 中查找方法
-	fm := reflect.ValueOf(s.RefTable().Model).MethodByName(method)
+	var fm reflect.Value
+	if model := s.RefTable().Model; model != nil {
+		fm = reflect.ValueOf(model).MethodByName(method)
+	}
 	if value != nil {
 		// 从提供的值中查找方法
 		fm = reflect.ValueOf(value).MethodByName(method)
@@ -0,0 +1,671 @@
+// 用于放置修改表结构相关的代码
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"geeorm/dialect"
+	"geeorm/log"
+	"geeorm/schema"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// TableColumns 返回当前模型对应的表在数据库中的列名
+//
+// 返回值:
+// []string: 按表中顺序排列的列名
+// error: 如果查询失败（例如表不存在），返回错误信息
+func (s *Session) TableColumns() ([]string, error) {
+	table := s.RefTable()
+	if table == nil {
+		return nil, errors.New("model is not set")
+	}
+	rows, err := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 0", s.dialect.Quote(table.Name))).QueryRows()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	return rows.Columns()
+}
+
+// ColumnTypes 读取当前模型对应的表中各列的定义，见 dialect.Dialect.ColumnTypes
+func (s *Session) ColumnTypes() ([]dialect.Column, error) {
+	if s.RefTable() == nil {
+		return nil, errors.New("model is not set")
+	}
+	return s.dialect.ColumnTypes(s.DB(), s.refTable.Name)
+}
+
+// Indexes 读取当前模型对应的表上的索引，见 dialect.Dialect.Indexes
+func (s *Session) Indexes() ([]dialect.Index, error) {
+	if s.RefTable() == nil {
+		return nil, errors.New("model is not set")
+	}
+	return s.dialect.Indexes(s.DB(), s.refTable.Name)
+}
+
+// Constraints 读取当前模型对应的表上的约束，见 dialect.Dialect.Constraints
+func (s *Session) Constraints() ([]dialect.Constraint, error) {
+	if s.RefTable() == nil {
+		return nil, errors.New("model is not set")
+	}
+	return s.dialect.Constraints(s.DB(), s.refTable.Name)
+}
+
+// ErrDestructiveMigration 表示迁移会删除列中的数据，需要通过 AllowDestructive 明确允许
+var ErrDestructiveMigration = errors.New("destructive migration is not allowed")
+
+// AllowDestructive 允许下一次 MigrateTable 删除模型中不存在的列，返回值是 *Session 可以链式调用
+//
+// 示例:
+// err := s.Model(&User{}).AllowDestructive().MigrateTable()
+func (s *Session) AllowDestructive() *Session {
+	s.allowDestructive = true
+	return s
+}
+
+// ColumnChange 表示定义发生变化的一列
+type ColumnChange struct {
+	Name string         // 列名
+	From dialect.Column // 表中的定义
+	To   dialect.Column // 模型中的定义
+}
+
+// TableDiff 表示模型与数据库中的表之间的差异
+type TableDiff struct {
+	Table              string         // 表名
+	Create             bool           // 表不存在，需要创建
+	AddedColumns       []string       // 模型中新增的列
+	RemovedColumns     []string       // 模型中不存在的列
+	ChangedColumns     []ColumnChange // 类型、NOT NULL、默认值、主键或生成列的表达式发生变化的列
+	AddedForeignKeys   []string       // 模型中新增的外键约束，例如 AuthorID -> User(Name) ON DELETE CASCADE ON UPDATE NO ACTION
+	RemovedForeignKeys []string       // 模型中不存在的外键约束
+	AddedChecks        []string       // 标签中新声明的 CHECK 约束的表达式
+	RemovedChecks      []string       // 表上存在而标签中没有声明的 CHECK 约束的表达式
+	AddedIndexes       []string       // 标签中新声明的索引
+	ChangedIndexes     []string       // 定义与标签中的声明不同的索引，需要删除后重新创建
+	RemovedIndexes     []string       // 因为引用了被删除的列而被删除的索引
+}
+
+// Empty 判断模型与表之间是否没有差异
+func (d *TableDiff) Empty() bool {
+	return !d.Create && len(d.AddedColumns) == 0 && len(d.RemovedColumns) == 0 && len(d.ChangedColumns) == 0 &&
+		len(d.AddedForeignKeys) == 0 && len(d.RemovedForeignKeys) == 0 && len(d.AddedChecks) == 0 && len(d.RemovedChecks) == 0 &&
+		len(d.AddedIndexes) == 0 && len(d.ChangedIndexes) == 0
+}
+
+// Destructive 判断修改表结构是否会删除列中的数据
+func (d *TableDiff) Destructive() bool {
+	return len(d.RemovedColumns) > 0
+}
+
+// MigrationPlan 表示修改表结构的计划
+type MigrationPlan struct {
+	Diffs      []*TableDiff // 各个表的差异
+	Statements []string     // 按执行顺序排列的 DDL 语句
+}
+
+// Destructive 判断计划是否会删除列中的数据
+func (p *MigrationPlan) Destructive() bool {
+	for _, diff := range p.Diffs {
+		if diff.Destructive() {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffTable 比较当前模型与数据库中对应的表
+//
+// 返回值:
+// *TableDiff: 模型与表之间的差异
+// error: 如果读取表结构失败，返回错误信息
+//
+// 列的类型不区分大小写比较，NOT NULL、默认值和主键根据模型字段的标签判断；
+// 索引按名称与标签中的声明比较，没有在标签中声明的索引不会被修改
+func (s *Session) DiffTable() (*TableDiff, error) {
+	table := s.RefTable()
+	if table == nil {
+		return nil, errors.New("model is not set")
+	}
+	diff := &TableDiff{Table: table.Name}
+	if !s.HasTable() {
+		diff.Create = true
+		for _, index := range table.Indexes {
+			diff.AddedIndexes = append(diff.AddedIndexes, index.Name)
+		}
+		return diff, nil
+	}
+	columns, err := s.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]dialect.Column, len(columns))
+	for _, column := range columns {
+		existing[column.Name] = column
+		if table.GetField(column.Name) == nil {
+			diff.RemovedColumns = append(diff.RemovedColumns, column.Name)
+		}
+	}
+	for _, field := range table.Fields {
+		from, ok := existing[field.Name]
+		if !ok {
+			diff.AddedColumns = append(diff.AddedColumns, field.Name)
+			continue
+		}
+		to := fieldColumn(field)
+		if !strings.EqualFold(from.Type, to.Type) || from.NotNull != to.NotNull || !sameDefault(from.Default, to.Default) ||
+			from.PrimaryKey != to.PrimaryKey || from.Generated != to.Generated || from.Stored != to.Stored {
+			diff.ChangedColumns = append(diff.ChangedColumns, ColumnChange{Name: field.Name, From: from, To: to})
+		}
+	}
+	if err = s.diffConstraints(diff); err != nil {
+		return nil, err
+	}
+	indexes, err := s.Indexes()
+	if err != nil {
+		return nil, err
+	}
+	existingIndexes := make(map[string]dialect.Index, len(indexes))
+	for _, index := range indexes {
+		existingIndexes[index.Name] = index
+	}
+	for _, index := range table.Indexes {
+		if from, ok := existingIndexes[index.Name]; !ok {
+			diff.AddedIndexes = append(diff.AddedIndexes, index.Name)
+		} else if from.SQL != s.createIndexSQL(index) {
+			diff.ChangedIndexes = append(diff.ChangedIndexes, index.Name)
+		}
+	}
+	return diff, nil
+}
+
+// sameDefault 判断数据库中的默认值 a 与模型中的默认值 b 是否相同
+//
+// 数据库返回的默认值可能省略了表达式外层的括号，例如 DEFAULT (1+1) 读取为 1+1，
+// 因此去掉两边外层的一对括号后不区分大小写比较
+func sameDefault(a, b string) bool {
+	return strings.EqualFold(trimParens(a), trimParens(b))
+}
+
+// trimParens 去掉包裹整个表达式 expr 的一对括号，例如 (1+1) => 1+1，(1)+(2) 保持不变
+func trimParens(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		return expr
+	}
+	depth, quoted := 0, false
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\'':
+			quoted = !quoted
+		case quoted:
+		case expr[i] == '(':
+			depth++
+		case expr[i] == ')':
+			if depth--; depth == 0 && i < len(expr)-1 {
+				return expr
+			}
+		}
+	}
+	return strings.TrimSpace(expr[1 : len(expr)-1])
+}
+
+// diffConstraints 比较模型中的外键和 CHECK 约束与表上的约束，将差异记录在 diff 中
+//
+// CHECK 约束按表达式比较，列定义中的 CHECK 约束属于列的标签，不参与比较
+func (s *Session) diffConstraints(diff *TableDiff) error {
+	constraints, err := s.Constraints()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	checks := make(map[string]bool)
+	for _, c := range constraints {
+		switch {
+		case c.Type == dialect.CheckConstraint && len(c.Columns) == 0:
+			checks[c.Expression] = true
+		case c.Type == dialect.ForeignKeyConstraint:
+			refColumn := ""
+			if len(c.RefColumns) > 0 {
+				refColumn = strings.Join(c.RefColumns, ", ")
+			}
+			existing[foreignKeyKey(strings.Join(c.Columns, ", "), c.RefTable, refColumn, c.OnDelete, c.OnUpdate)] = true
+		}
+	}
+	for _, check := range s.refTable.Checks {
+		if checks[check.Expression] {
+			delete(checks, check.Expression)
+		} else {
+			diff.AddedChecks = append(diff.AddedChecks, check.Expression)
+		}
+	}
+	for expr := range checks {
+		diff.RemovedChecks = append(diff.RemovedChecks, expr)
+	}
+	sort.Strings(diff.RemovedChecks)
+	for _, fk := range s.refTable.ForeignKeys {
+		key := foreignKeyKey(fk.Column, fk.RefTable, fk.RefColumn, fk.OnDelete, fk.OnUpdate)
+		if existing[key] {
+			delete(existing, key)
+		} else {
+			diff.AddedForeignKeys = append(diff.AddedForeignKeys, key)
+		}
+	}
+	for key := range existing {
+		diff.RemovedForeignKeys = append(diff.RemovedForeignKeys, key)
+	}
+	sort.Strings(diff.RemovedForeignKeys)
+	return nil
+}
+
+// foreignKeyKey 返回外键的描述，用于比较模型与表上的外键约束，没有指定的动作视为 NO ACTION
+func foreignKeyKey(column, refTable, refColumn, onDelete, onUpdate string) string {
+	if onDelete == "" {
+		onDelete = "NO ACTION"
+	}
+	if onUpdate == "" {
+		onUpdate = "NO ACTION"
+	}
+	return fmt.Sprintf("%s -> %s(%s) ON DELETE %s ON UPDATE %s", column, refTable, refColumn, onDelete, onUpdate)
+}
+
+// PlanMigration 计算使数据库中的表与当前模型一致所需的 DDL 语句，只读取表结构，不修改数据库
+//
+// 返回值:
+// *MigrationPlan: 表的差异和按执行顺序排列的 DDL 语句
+// error: 如果读取表结构失败，返回错误信息
+//
+// 表不存在时创建表；只有新增的列且可以直接添加时使用 ALTER TABLE ... ADD COLUMN，
+// 方言支持 dialect.DropColumn 时使用 ALTER TABLE ... DROP COLUMN 删除列；
+// 创建标签中新声明的索引，删除并重新创建定义发生变化的索引；
+// 列的定义、外键或 CHECK 约束发生变化，新增的列带有主键、UNIQUE、没有默认值的 NOT NULL 约束或者是 STORED 生成列时，
+// 按 RebuildTable 的步骤重建表
+func (s *Session) PlanMigration() (*MigrationPlan, error) {
+	diff, err := s.DiffTable()
+	if err != nil {
+		return nil, err
+	}
+	table := s.refTable
+	plan := &MigrationPlan{Diffs: []*TableDiff{diff}}
+	if diff.Create {
+		plan.Statements = []string{createTableSQL(table, table.Name)}
+		plan.Statements = append(plan.Statements, s.createIndexesSQL(diff.AddedIndexes)...)
+		return plan, nil
+	}
+	rebuild := len(diff.ChangedColumns) > 0 || len(diff.AddedForeignKeys) > 0 || len(diff.RemovedForeignKeys) > 0 ||
+		len(diff.AddedChecks) > 0 || len(diff.RemovedChecks) > 0 ||
+		len(diff.RemovedColumns) > 0 && !s.canDropColumns(diff.RemovedColumns)
+	for _, name := range diff.AddedColumns {
+		if !canAddColumn(table.GetField(name)) {
+			rebuild = true
+		}
+	}
+	if rebuild {
+		if plan.Statements, err = s.rebuildSQL(diff); err != nil {
+			return nil, err
+		}
+		plan.Statements = append(plan.Statements, s.createIndexesSQL(append(diff.AddedIndexes, diff.ChangedIndexes...))...)
+		return plan, nil
+	}
+	for _, name := range diff.AddedColumns {
+		f := table.GetField(name)
+		plan.Statements = append(plan.Statements,
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", s.dialect.Quote(table.Name), columnSQL(f)))
+	}
+	plan.Statements = append(plan.Statements, s.dropColumnsSQL(diff.RemovedColumns)...)
+	for _, name := range diff.ChangedIndexes {
+		plan.Statements = append(plan.Statements, fmt.Sprintf("DROP INDEX %s", s.dialect.Quote(name)))
+	}
+	plan.Statements = append(plan.Statements, s.createIndexesSQL(append(diff.AddedIndexes, diff.ChangedIndexes...))...)
+	return plan, nil
+}
+
+// createIndexesSQL 返回创建标签中声明的索引 names 的 SQL 语句
+func (s *Session) createIndexesSQL(names []string) []string {
+	var statements []string
+	for _, name := range names {
+		statements = append(statements, s.createIndexSQL(s.refTable.GetIndex(name)))
+	}
+	return statements
+}
+
+// MigrateTable 在事务中修改数据库中的表，使其与当前模型一致，见 PlanMigration 和 MigrateTx
+//
+// 返回值:
+// error: 如果修改过程中发生错误，返回错误信息，此时事务被回滚；
+// 需要删除列而没有调用 AllowDestructive 时，不修改数据库并返回 ErrDestructiveMigration
+func (s *Session) MigrateTable() error {
+	allow := s.allowDestructive
+	s.allowDestructive = false
+	return s.MigrateTx(func(tx *Session) error {
+		plan, err := tx.PlanMigration()
+		if err != nil {
+			return err
+		}
+		diff := plan.Diffs[0]
+		if diff.Destructive() && !allow {
+			return fmt.Errorf("%w: dropping columns %v of table %s", ErrDestructiveMigration, diff.RemovedColumns, diff.Table)
+		}
+		log.Infof("migrate table %s: added cols %v, deleted cols %v, changed cols %d",
+			diff.Table, diff.AddedColumns, diff.RemovedColumns, len(diff.ChangedColumns))
+		return tx.execStatements(plan.Statements)
+	})
+}
+
+// MigrateTx 在关闭了外键约束检查的事务中执行修改表结构的函数 f
+//
+// 参数:
+// f: 要执行的函数，参数为事务所在的会话，它继承当前会话的模型
+//
+// 返回值:
+// error: f 返回的错误，或者 f 引入了新的违反外键约束的记录时返回错误信息，此时事务被回滚
+//
+// 重建表时删除原表会触发外键的 ON DELETE 动作，因此按照 SQLite 推荐的步骤，在单独的连接上关闭外键约束检查后开始事务，
+// 提交前检查外键约束，结束后恢复该连接的外键约束检查；
+// 当前会话已经处于事务中时直接执行 f，此时需要调用者自行关闭外键约束检查
+func (s *Session) MigrateTx(f func(tx *Session) error) (err error) {
+	if s.tx != nil {
+		return f(s)
+	}
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	var enabled bool
+	if query := s.dialect.ForeignKeysEnabledSQL(); query != "" {
+		if err = conn.QueryRowContext(ctx, query).Scan(&enabled); err != nil {
+			return err
+		}
+	}
+	if enabled {
+		if _, err = conn.ExecContext(ctx, s.dialect.ForeignKeysSQL(false)); err != nil {
+			return err
+		}
+		defer func() { _, _ = conn.ExecContext(ctx, s.dialect.ForeignKeysSQL(true)) }()
+	}
+	tx := New(s.db, s.dialect)
+	tx.refTable = s.refTable
+	log.Info("transaction begin")
+	if tx.tx, err = conn.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	if !enabled {
+		return f(tx)
+	}
+	// 数据库中已有的违反外键约束的记录与本次修改无关，只检查 f 新引入的
+	before, err := tx.foreignKeyViolations()
+	if err != nil {
+		return err
+	}
+	if err = f(tx); err != nil {
+		return err
+	}
+	return tx.checkForeignKeys(before)
+}
+
+// checkForeignKeys 检查数据库中是否存在 before 之外的违反外键约束的记录
+func (s *Session) checkForeignKeys(before map[string]bool) error {
+	violations, err := s.foreignKeyViolations()
+	if err != nil {
+		return err
+	}
+	for v := range violations {
+		if !before[v] {
+			return fmt.Errorf("foreign key constraint violated: %s", v)
+		}
+	}
+	return nil
+}
+
+// foreignKeyViolations 返回数据库中违反外键约束的记录，每条记录格式化为字符串，方言不支持检查时返回空
+func (s *Session) foreignKeyViolations() (map[string]bool, error) {
+	check := s.dialect.ForeignKeyCheckSQL()
+	if check == "" {
+		return nil, nil
+	}
+	var rows []map[string]interface{}
+	if err := s.Raw(check).Scan(&rows); err != nil {
+		return nil, err
+	}
+	violations := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		violations[fmt.Sprint(row)] = true
+	}
+	return violations, nil
+}
+
+// fieldColumn 返回模型字段 f 对应的列定义
+func fieldColumn(f *schema.Field) dialect.Column {
+	return dialect.Column{Name: f.Name, Type: f.Type, NotNull: f.NotNull, Default: f.Default, PrimaryKey: f.PrimaryKey,
+		Generated: f.Generated, Stored: f.Stored}
+}
+
+// canAddColumn 判断字段 f 对应的列能否通过 ALTER TABLE ... ADD COLUMN 添加到已有数据的表中
+func canAddColumn(f *schema.Field) bool {
+	return !f.PrimaryKey && !strings.Contains(strings.ToUpper(f.Tag), "UNIQUE") && (!f.NotNull || f.Default != "") &&
+		!(f.Generated != "" && f.Stored)
+}
+
+// DropColumns 删除当前模型对应的表中的列 columns
+//
+// 参数:
+// columns: 要删除的列名，它们不应该出现在模型中
+//
+// 返回值:
+// error: 如果删除过程中发生错误，返回错误信息
+//
+// 方言支持 dialect.DropColumn 时使用 ALTER TABLE ... DROP COLUMN，否则通过 RebuildTable 重建表
+func (s *Session) DropColumns(columns ...string) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	if s.RefTable() == nil {
+		return errors.New("model is not set")
+	}
+	if !s.canDropColumns(columns) {
+		return s.RebuildTable()
+	}
+	return s.MigrateTx(func(tx *Session) error {
+		return tx.execStatements(tx.dropColumnsSQL(columns))
+	})
+}
+
+// canDropColumns 判断能否通过 ALTER TABLE ... DROP COLUMN 删除列 columns
+//
+// 方言需要支持 dialect.DropColumn，并且这些列不是主键、不在索引、UNIQUE、外键和 CHECK 约束中、
+// 没有被生成列或者其他表的外键引用；读取表结构失败时返回 false，此时通过重建表删除列
+func (s *Session) canDropColumns(columns []string) bool {
+	if !s.dialect.Supports(dialect.DropColumn) {
+		return false
+	}
+	table := s.refTable.Name
+	mentions := func(expr string) bool {
+		for _, column := range columns {
+			if regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(column) + `\b`).MatchString(expr) {
+				return true
+			}
+		}
+		return false
+	}
+	involved := func(names []string) bool {
+		for _, name := range names {
+			if slices.Contains(columns, name) {
+				return true
+			}
+		}
+		return false
+	}
+	existing, err := s.ColumnTypes()
+	if err != nil {
+		return false
+	}
+	for _, c := range existing {
+		if (c.PrimaryKey && slices.Contains(columns, c.Name)) || (c.Generated != "" && mentions(c.Generated)) {
+			return false
+		}
+	}
+	indexes, err := s.Indexes()
+	if err != nil {
+		return false
+	}
+	for _, index := range indexes {
+		if involved(index.Columns) || (index.SQL != "" && mentions(index.SQL)) {
+			return false
+		}
+	}
+	constraints, err := s.Constraints()
+	if err != nil {
+		return false
+	}
+	for _, c := range constraints {
+		if involved(c.Columns) || (c.Type == dialect.CheckConstraint && mentions(c.Expression)) {
+			return false
+		}
+	}
+	tables, err := s.dialect.Tables(s.DB())
+	if err != nil {
+		return false
+	}
+	for _, other := range tables {
+		if other == table {
+			continue
+		}
+		constraints, err := s.dialect.Constraints(s.DB(), other)
+		if err != nil {
+			return false
+		}
+		for _, c := range constraints {
+			if c.Type == dialect.ForeignKeyConstraint && strings.EqualFold(c.RefTable, table) && involved(c.RefColumns) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dropColumnsSQL 返回删除列 columns 的 ALTER TABLE 语句
+func (s *Session) dropColumnsSQL(columns []string) []string {
+	var statements []string
+	for _, column := range columns {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
+			s.dialect.Quote(s.refTable.Name), s.dialect.Quote(column)))
+	}
+	return statements
+}
+
+// RebuildTable 按照当前模型的完整定义重建表，保留模型与原表共有的列中的数据
+//
+// 返回值:
+// error: 如果重建过程中发生错误，返回错误信息，此时事务被回滚，原表保持不变
+//
+// 按照 SQLite 推荐的步骤在关闭了外键约束检查的事务中执行，见 MigrateTx：
+//  1. 记录原表上的索引和触发器定义
+//  2. 按模型的定义（包括类型和约束）创建新表
+//  3. 将共有列的数据复制到新表
+//  4. 删除原表，并将新表重命名为原表名
+//  5. 重新创建原表上的索引和触发器，引用了被删除的列的索引随列一起被删除
+//
+// 引用了被删除的列的触发器无法重新创建，需要先删除它们
+func (s *Session) RebuildTable() error {
+	diff, err := s.DiffTable()
+	if err != nil {
+		return err
+	}
+	if diff.Create {
+		return fmt.Errorf("table %s doesn't exist", diff.Table)
+	}
+	return s.MigrateTx(func(tx *Session) error {
+		statements, err := tx.rebuildSQL(diff)
+		if err != nil {
+			return err
+		}
+		return tx.execStatements(statements)
+	})
+}
+
+// rebuildSQL 返回按 RebuildTable 的步骤重建表的语句，并将被删除的索引记录在 diff 中
+func (s *Session) rebuildSQL(diff *TableDiff) ([]string, error) {
+	table := s.refTable
+	existing, err := s.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var objects []string
+	sql, vars := s.dialect.TableObjectsSQL(table.Name)
+	if err = s.prepare(sql, vars...).Scan(&objects); err != nil {
+		return nil, err
+	}
+	indexes, err := s.Indexes()
+	if err != nil {
+		return nil, err
+	}
+	// 定义发生变化的索引在重建表之后按新的定义创建
+	removed := make(map[string]bool)
+	for _, name := range diff.ChangedIndexes {
+		for _, index := range indexes {
+			if index.Name == name {
+				removed[index.SQL] = true
+			}
+		}
+	}
+	for _, index := range indexes {
+		for _, column := range index.Columns {
+			if column != "" && table.GetField(column) == nil && index.SQL != "" {
+				removed[index.SQL] = true
+				diff.RemovedIndexes = append(diff.RemovedIndexes, index.Name)
+				break
+			}
+		}
+	}
+	// 生成列的值由数据库计算，不需要复制
+	var columns []string
+	for _, column := range existing {
+		if f := table.GetField(column.Name); f != nil && f.Generated == "" && column.Generated == "" {
+			columns = append(columns, s.dialect.Quote(column.Name))
+		}
+	}
+	name, tmp := s.dialect.Quote(table.Name), s.dialect.Quote("geeorm_new_"+table.Name)
+	statements := []string{createTableSQL(table, tmp)}
+	if len(columns) > 0 {
+		list := strings.Join(columns, ", ")
+		statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", tmp, list, list, name))
+	}
+	statements = append(statements,
+		fmt.Sprintf("DROP TABLE %s", name),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tmp, name))
+	for _, object := range objects {
+		if !removed[object] {
+			statements = append(statements, object)
+		}
+	}
+	return statements, nil
+}
+
+// execStatements 在事务中依次执行 statements
+func (s *Session) execStatements(statements []string) error {
+	return s.runInTx(func(tx *Session) error {
+		for _, stmt := range statements {
+			if _, err := tx.Raw(stmt).Exec(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,234 @@
+package session
+
+import (
+	"errors"
+	"geeorm/dialect"
+	"reflect"
+	"testing"
+)
+
+// dropColumnDialect 声明支持 DROP COLUMN，用于测试不需要重建表的删除列语句
+type dropColumnDialect struct {
+	dialect.Dialect
+}
+
+func (d *dropColumnDialect) Supports(feature dialect.Feature) bool {
+	return feature == dialect.DropColumn || d.Dialect.Supports(feature)
+}
+
+func TestSession_DropColumns(t *testing.T) {
+	for _, d := range []dialect.Dialect{TestDial, &dropColumnDialect{TestDial}} {
+		s := New(TestDB, d)
+		_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+		_, _ = s.Raw("CREATE TABLE User(Name text PRIMARY KEY, XXX integer, Age integer);").Exec()
+		_, _ = s.Raw("INSERT INTO User(Name, XXX, Age) values (?, ?, ?)", "Tom", 1, 18).Exec()
+		if err := s.Model(&User{}).DropColumns("XXX"); err != nil {
+			t.Fatal("failed to drop column", err)
+		}
+		columns, err := s.TableColumns()
+		if err != nil || !reflect.DeepEqual(columns, []string{"Name", "Age"}) {
+			t.Fatal("failed to drop column", columns, err)
+		}
+		if n, _ := s.Where("Age = ?", 18).Count(); n != 1 {
+			t.Fatal("failed to keep data", n)
+		}
+	}
+}
+
+func TestSession_DropConstrainedColumns(t *testing.T) {
+	s := New(TestDB, &dropColumnDialect{TestDial}).Model(&User{})
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text PRIMARY KEY, XXX integer UNIQUE, YYY integer, Age integer);").Exec()
+	_, _ = s.Raw("CREATE INDEX idx_user_yyy ON User(YYY);").Exec()
+	_, _ = s.Raw("INSERT INTO User(Name, XXX, YYY, Age) values (?, ?, ?, ?)", "Tom", 1, 2, 18).Exec()
+	for _, column := range []string{"Name", "XXX", "YYY"} {
+		if s.canDropColumns([]string{column}) {
+			t.Fatalf("expected column %s to be dropped by rebuilding the table", column)
+		}
+	}
+	if !s.canDropColumns([]string{"Age"}) {
+		t.Fatal("expected column Age to be dropped in place")
+	}
+	if err := s.DropColumns("XXX", "YYY"); err != nil {
+		t.Fatal("failed to drop constrained columns", err)
+	}
+	columns, err := s.TableColumns()
+	if err != nil || !reflect.DeepEqual(columns, []string{"Name", "Age"}) {
+		t.Fatal("failed to drop constrained columns", columns, err)
+	}
+	if n, _ := s.Where("Age = ?", 18).Count(); n != 1 {
+		t.Fatal("failed to keep data", n)
+	}
+}
+
+func TestSession_Introspect(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text PRIMARY KEY, Age integer NOT NULL DEFAULT 18, Email text UNIQUE);").Exec()
+	_, _ = s.Raw("CREATE INDEX idx_user_age ON User(Age);").Exec()
+	s.Model(&User{})
+	columns, err := s.ColumnTypes()
+	if err != nil || len(columns) != 3 || !reflect.DeepEqual(columns[1],
+		dialect.Column{Name: "Age", Type: "INTEGER", NotNull: true, Default: "18"}) || !columns[0].PrimaryKey {
+		t.Fatal("failed to read columns", columns, err)
+	}
+	indexes, err := s.Indexes()
+	if err != nil || len(indexes) != 3 || indexes[0].Name != "idx_user_age" ||
+		!reflect.DeepEqual(indexes[0].Columns, []string{"Age"}) || indexes[0].SQL == "" {
+		t.Fatal("failed to read indexes", indexes, err)
+	}
+	constraints, err := s.Constraints()
+	if err != nil || !reflect.DeepEqual(constraints, []dialect.Constraint{
+		{Type: dialect.PrimaryKeyConstraint, Columns: []string{"Name"}},
+		{Type: dialect.UniqueConstraint, Columns: []string{"Email"}},
+	}) {
+		t.Fatal("failed to read constraints", constraints, err)
+	}
+}
+
+type Person struct {
+	Name string `geeorm:"PRIMARY KEY"`
+	Age  string `geeorm:"NOT NULL DEFAULT 'unknown'"`
+	City string `geeorm:"DEFAULT 'Beijing'"`
+}
+
+func TestSession_MigrateTable(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS Person;").Exec()
+	_, _ = s.Raw("CREATE TABLE Person(Name text PRIMARY KEY, Age integer, Note text);").Exec()
+	_, _ = s.Raw("INSERT INTO Person(Name, Age, Note) values (?, ?, ?)", "Tom", 18, "x").Exec()
+	diff, err := s.Model(&Person{}).DiffTable()
+	if err != nil || !reflect.DeepEqual(diff.AddedColumns, []string{"City"}) ||
+		!reflect.DeepEqual(diff.RemovedColumns, []string{"Note"}) || len(diff.ChangedColumns) != 1 ||
+		diff.ChangedColumns[0].To.Default != "'unknown'" {
+		t.Fatal("failed to diff table", diff, err)
+	}
+	if err = s.MigrateTable(); !errors.Is(err, ErrDestructiveMigration) {
+		t.Fatal("expect destructive migration to be refused", err)
+	}
+	if err = s.AllowDestructive().MigrateTable(); err != nil {
+		t.Fatal("failed to migrate table", err)
+	}
+	if diff, err = s.DiffTable(); err != nil || !diff.Empty() {
+		t.Fatal("table should match the model after migration", diff, err)
+	}
+	p := &Person{}
+	if err = s.First(p); err != nil || p.Age != "18" || p.City != "Beijing" {
+		t.Fatal("failed to keep data", p, err)
+	}
+}
+
+type Teacher struct {
+	Name string `geeorm:"PRIMARY KEY"`
+	Age  int    `geeorm:"index:idx_teacher_age,where:Age > 18"`
+	City string `geeorm:"index"`
+}
+
+func TestSession_MigrateIndexes(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS Teacher;").Exec()
+	_, _ = s.Raw("CREATE TABLE Teacher(Name text PRIMARY KEY, Age integer);").Exec()
+	_, _ = s.Raw("CREATE INDEX idx_teacher_age ON Teacher(Age);").Exec()
+	diff, err := s.Model(&Teacher{}).DiffTable()
+	if err != nil || !reflect.DeepEqual(diff.AddedIndexes, []string{"idx_Teacher_City"}) ||
+		!reflect.DeepEqual(diff.ChangedIndexes, []string{"idx_teacher_age"}) {
+		t.Fatal("failed to diff indexes", diff, err)
+	}
+	if err = s.MigrateTable(); err != nil {
+		t.Fatal("failed to migrate indexes", err)
+	}
+	if diff, err = s.DiffTable(); err != nil || !diff.Empty() {
+		t.Fatal("indexes should match the model after migration", diff, err)
+	}
+}
+
+type Lesson struct {
+	ID          int64 `geeorm:"PRIMARY KEY"`
+	TeacherName string
+	Teacher     Teacher `geeorm:"foreignKey:TeacherName;constraint:OnDelete:SET NULL"`
+}
+
+func TestSession_DiffForeignKeys(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS Lesson;").Exec()
+	_, _ = s.Raw("CREATE TABLE Lesson(ID integer PRIMARY KEY, TeacherName text REFERENCES User(Name));").Exec()
+	diff, err := s.Model(&Lesson{}).DiffTable()
+	if err != nil || !reflect.DeepEqual(diff.AddedForeignKeys,
+		[]string{"TeacherName -> Teacher(Name) ON DELETE SET NULL ON UPDATE NO ACTION"}) ||
+		!reflect.DeepEqual(diff.RemovedForeignKeys, []string{"TeacherName -> User(Name) ON DELETE NO ACTION ON UPDATE NO ACTION"}) {
+		t.Fatal("failed to diff foreign keys", diff, err)
+	}
+	if err = s.MigrateTable(); err != nil {
+		t.Fatal("failed to migrate foreign keys", err)
+	}
+	if diff, err = s.DiffTable(); err != nil || !diff.Empty() {
+		t.Fatal("foreign keys should match the model after migration", diff, err)
+	}
+}
+
+type Course struct {
+	Name  string `geeorm:"PRIMARY KEY"`
+	Hours int    `geeorm:"check:Hours > 0"`
+	Weeks int    `geeorm:"generated:Hours / 2,stored"`
+}
+
+func TestSession_MigrateCheckAndGenerated(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS Course;").Exec()
+	_, _ = s.Raw("CREATE TABLE Course(Name text PRIMARY KEY, Hours integer, Weeks integer AS (Hours / 4), CHECK (Hours < 100));").Exec()
+	_, _ = s.Raw("INSERT INTO Course(Name, Hours) VALUES ('Go', 40);").Exec()
+	columns, _ := s.Model(&Course{}).ColumnTypes()
+	if len(columns) != 3 || columns[2].Generated != "Hours / 4" || columns[2].Stored {
+		t.Fatal("failed to introspect generated column", columns)
+	}
+	diff, err := s.DiffTable()
+	if err != nil || !reflect.DeepEqual(diff.AddedChecks, []string{"Hours > 0"}) ||
+		!reflect.DeepEqual(diff.RemovedChecks, []string{"Hours < 100"}) ||
+		len(diff.ChangedColumns) != 1 || diff.ChangedColumns[0].Name != "Weeks" {
+		t.Fatal("failed to diff check constraints and generated columns", diff, err)
+	}
+	if err = s.MigrateTable(); err != nil {
+		t.Fatal("failed to migrate check constraints and generated columns", err)
+	}
+	if diff, err = s.DiffTable(); err != nil || !diff.Empty() {
+		t.Fatal("table should match the model after migration", diff, err)
+	}
+	c := &Course{}
+	if err = s.First(c); err != nil || c.Hours != 40 || c.Weeks != 20 {
+		t.Fatal("failed to keep data", c, err)
+	}
+	constraints, _ := s.Constraints()
+	var checks []string
+	for _, constraint := range constraints {
+		if constraint.Type == dialect.CheckConstraint {
+			checks = append(checks, constraint.Name+": "+constraint.Expression)
+		}
+	}
+	if !reflect.DeepEqual(checks, []string{"chk_Course_Hours: Hours > 0"}) {
+		t.Fatal("failed to introspect check constraints", checks)
+	}
+}
+
+type Event struct {
+	Name      string `geeorm:"PRIMARY KEY"`
+	CreatedAt string `geeorm:"DEFAULT (datetime('now'))"`
+	Priority  int    `geeorm:"DEFAULT (1+1)"`
+}
+
+func TestSession_MigrateExpressionDefaults(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS Event;").Exec()
+	if f := s.Model(&Event{}).RefTable().GetField("CreatedAt"); f.Default != "(datetime('now'))" {
+		t.Fatal("failed to parse default expression", f.Default)
+	}
+	if err := s.MigrateTable(); err != nil {
+		t.Fatal("failed to create table", err)
+	}
+	if err := s.MigrateTable(); err != nil {
+		t.Fatal("failed to migrate table", err)
+	}
+	plan, err := s.PlanMigration()
+	if err != nil || len(plan.Statements) != 0 {
+		t.Fatal("expression defaults should not plan any statement", plan, err)
+	}
+}
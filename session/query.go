@@ -0,0 +1,227 @@
+// 用于放置构建查询语句和子查询相关的代码
+package session
+
+import (
+	"errors"
+	"fmt"
+	"geeorm/clause"
+	"geeorm/dialect"
+	"geeorm/schema"
+)
+
+// Table 指定查询的表，返回值是 *Session 可以链式调用
+//
+// 参数:
+// name: 表名，或者包含占位符的表表达式
+// args: 占位符的值，可以是未执行的 *Session 子查询
+//
+// 示例:
+// s.Table("User")
+// s.Table("? AS u", NewSession().Model(&User{}).Where("Age > ?", 18))
+func (s *Session) Table(name string, args ...interface{}) *Session {
+	s.table, s.tableVars = clause.ExpandVars(name, s.subqueries(args))
+	return s
+}
+
+// buildSelect 根据 Model、Table、Select 和各子句构建 SELECT 语句
+//
+// 返回值:
+// string: 构建的 SELECT 语句
+// []interface{}: SELECT 语句对应的参数
+// error: 如果会话中记录了错误，或者选择的列不存在，返回错误信息
+//
+// 只构建语句，不执行也不清空会话，因此同一个会话可以多次作为子查询使用
+func (s *Session) buildSelect() (string, []interface{}, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
+	tableName := s.table
+	fields := []string{"*"}
+	var vars []interface{}
+	if s.refTable != nil {
+		if tableName == "" {
+			tableName = s.refTable.Name
+		}
+		var err error
+		if fields, vars, err = s.selectList(s.refTable); err != nil {
+			return "", nil, err
+		}
+	} else if tableName == "" {
+		return "", nil, errors.New("model is not set")
+	} else if len(s.selects) > 0 || len(s.selectExprs) > 0 {
+		fields, vars = append(append([]string{}, s.selects...), s.selectExprs...), s.selectVars
+	}
+	vars = append(append([]interface{}{}, vars...), s.tableVars...)
+	s.clause.Set(clause.SELECT, append([]interface{}{tableName, fields, s.distinct}, vars...)...)
+	// 不支持行锁的数据库省略 FOR UPDATE 等语句
+	if s.lock != "" && s.dialect.Supports(dialect.RowLocking) {
+		s.clause.Set(clause.LOCK, s.lock, s.lockWait)
+	}
+	return s.clause.BuildStatement(clause.SelectStatement, s.dialect)
+}
+
+// selectList 返回 SELECT 语句的字段列表和其中占位符的值
+//
+// 没有调用 Select 和 SelectExpr 时使用经过 Omit 筛选后的所有列；
+// 否则按 Select 的顺序使用其中的列，之后是 SelectExpr 的表达式
+func (s *Session) selectList(table *schema.Schema) ([]string, []interface{}, error) {
+	if len(s.selects) == 0 && len(s.selectExprs) == 0 {
+		columns, err := s.columns(table)
+		return columns, nil, err
+	}
+	var fields []string
+	for _, item := range s.selects {
+		if table.GetField(item) == nil {
+			return nil, nil, fmt.Errorf("unknown column %s in table %s", item, table.Name)
+		}
+		if s.selected(item) {
+			fields = append(fields, item)
+		}
+	}
+	fields = append(fields, s.selectExprs...)
+	if len(fields) == 0 {
+		return nil, nil, fmt.Errorf("no columns selected in table %s", table.Name)
+	}
+	return fields, s.selectVars, nil
+}
+
+// Union 将当前查询与 queries 的结果合并并去重，返回值是 *Session 可以链式调用
+//
+// 当前会话的 ORDER BY 和 LIMIT 作用于整个复合查询的结果，queries 中不能包含 ORDER BY 和 LIMIT
+//
+// 示例:
+// var users []User
+// err := s.Model(&User{}).Where("Age < ?", 20).Union(NewSession().Model(&User{}).Where("Age > ?", 60)).OrderBy("Age").Find(&users)
+func (s *Session) Union(queries ...*Session) *Session {
+	return s.compound("UNION", queries)
+}
+
+// UnionAll 将当前查询与 queries 的结果合并且不去重，返回值是 *Session 可以链式调用
+func (s *Session) UnionAll(queries ...*Session) *Session {
+	return s.compound("UNION ALL", queries)
+}
+
+// Intersect 取当前查询与 queries 结果的交集，返回值是 *Session 可以链式调用
+func (s *Session) Intersect(queries ...*Session) *Session {
+	return s.compound("INTERSECT", queries)
+}
+
+// Except 从当前查询的结果中去除 queries 的结果，返回值是 *Session 可以链式调用
+func (s *Session) Except(queries ...*Session) *Session {
+	return s.compound("EXCEPT", queries)
+}
+
+// compound 以集合运算符 op 将 queries 追加到复合查询中
+func (s *Session) compound(op string, queries []*Session) *Session {
+	args := make([]interface{}, len(queries))
+	for i, query := range queries {
+		args[i] = query
+	}
+	for _, sub := range s.subqueries(args) {
+		s.compounds = append(s.compounds, op, sub)
+	}
+	s.clause.Set(clause.COMPOUND, s.compounds...)
+	return s
+}
+
+// With 在语句之前添加名为 name 的公用表表达式（CTE），返回值是 *Session 可以链式调用
+//
+// 参数:
+// name: CTE 名称，可以带列名，例如 "tree(ID, ParentID)"
+// query: 定义 CTE 的未执行的子查询
+//
+// 之后可以通过 Table(name) 从 CTE 中查询
+//
+// 示例:
+// s.With("adults", NewSession().Model(&User{}).Where("Age >= ?", 18)).Table("adults").Find(&users)
+func (s *Session) With(name string, query *Session) *Session {
+	for _, sub := range s.subqueries([]interface{}{query}) {
+		s.ctes = append(s.ctes, name, sub)
+	}
+	s.clause.Set(clause.WITH, append([]interface{}{s.recursive}, s.ctes...)...)
+	return s
+}
+
+// WithRecursive 与 With 相同，但使用 WITH RECURSIVE，允许 query 引用 CTE 自身，返回值是 *Session 可以链式调用
+//
+// 示例:
+//
+//	tree := NewSession().Raw("SELECT ID, ParentID FROM Category WHERE ID = ? "+
+//		"UNION ALL SELECT c.ID, c.ParentID FROM Category c JOIN tree ON c.ParentID = tree.ID", 1)
+//	s.WithRecursive("tree", tree).Table("tree").Find(&categories)
+func (s *Session) WithRecursive(name string, query *Session) *Session {
+	s.recursive = true
+	return s.With(name, query)
+}
+
+// ForUpdate 为查询的行加排他锁，即 SELECT ... FOR UPDATE，返回值是 *Session 可以链式调用
+//
+// 行锁需要在事务中使用，例如 Engine.Transaction；
+// 方言不支持 dialect.RowLocking 时（如 SQLite 在事务中锁定整个数据库）省略行锁语句
+//
+// 示例:
+//
+//	engine.Transaction(func(s *session.Session) (interface{}, error) {
+//		var jobs []Job
+//		err := s.Where("Status = ?", "pending").ForUpdate().SkipLocked().Limit(10).Find(&jobs)
+//		...
+//	})
+func (s *Session) ForUpdate() *Session {
+	s.lock = "UPDATE"
+	return s
+}
+
+// ForShare 为查询的行加共享锁，即 SELECT ... FOR SHARE，返回值是 *Session 可以链式调用
+func (s *Session) ForShare() *Session {
+	s.lock = "SHARE"
+	return s
+}
+
+// SkipLocked 跳过已被其他事务锁定的行，没有指定锁的强度时使用 FOR UPDATE，返回值是 *Session 可以链式调用
+func (s *Session) SkipLocked() *Session {
+	return s.lockOption("SKIP LOCKED")
+}
+
+// NoWait 遇到已被其他事务锁定的行时立即返回错误，没有指定锁的强度时使用 FOR UPDATE，返回值是 *Session 可以链式调用
+func (s *Session) NoWait() *Session {
+	return s.lockOption("NOWAIT")
+}
+
+// lockOption 设置行锁的等待选项
+func (s *Session) lockOption(option string) *Session {
+	if s.lock == "" {
+		s.lock = "UPDATE"
+	}
+	s.lockWait = option
+	return s
+}
+
+// subqueries 将参数中未执行的 *Session 构建为 clause.Subquery，以便内嵌到语句中
+//
+// 子查询优先使用通过 Raw 设置的 SQL 语句，否则根据其 Model 和各子句构建 SELECT 语句；
+// 构建失败的错误记录在当前会话中，在执行语句时返回
+func (s *Session) subqueries(args []interface{}) []interface{} {
+	var result []interface{}
+	for i, arg := range args {
+		sub, ok := arg.(*Session)
+		if !ok {
+			continue
+		}
+		if result == nil {
+			result = append([]interface{}{}, args...)
+		}
+		if sub.sql.Len() > 0 {
+			result[i] = clause.Subquery{SQL: sub.sql.String(), Vars: sub.sqlVars}
+			continue
+		}
+		sql, vars, err := sub.buildSelect()
+		if err != nil {
+			s.err = err
+		}
+		result[i] = clause.Subquery{SQL: sql, Vars: vars}
+	}
+	if result == nil {
+		return args
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"geeorm/dialect"
+)
+
+func TestSession_SubqueryWhere(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(&User{"Jack", 40})
+	avg := NewSession().Model(&User{}).SelectExpr("AVG(Age)").Where("Name <> ?", "Jack")
+	var users []User
+	if err := s.Where("Age > ?", avg).Find(&users); err != nil || len(users) != 2 {
+		t.Fatal("failed to query with scalar subquery", err, users)
+	}
+	names := NewSession().Model(&User{}).Select("Name").Where("Age < ?", 30)
+	users = nil
+	err := s.Where("Name IN ? AND Age > ?", names, 20).Find(&users)
+	if err != nil || len(users) != 1 || users[0].Name != "Sam" {
+		t.Fatal("failed to query with IN subquery", err, users)
+	}
+	// 子查询没有被执行，可以重复使用
+	sql, vars, err := names.buildSelect()
+	if err != nil || sql != "SELECT Name FROM User WHERE Age < ?" || !reflect.DeepEqual(vars, []interface{}{30}) {
+		t.Fatal("subquery session was changed", err, sql, vars)
+	}
+}
+
+func TestSession_SubquerySelectAndTable(t *testing.T) {
+	s := testRecordInit(t)
+	total := NewSession().Raw("SELECT count(*) FROM User WHERE Age >= ?", 18)
+	var result []struct {
+		Name  string
+		Total int
+	}
+	err := s.Model(&User{}).Select("Name").SelectExpr("? AS Total", total).OrderBy("Name").Scan(&result)
+	if err != nil || len(result) != 2 || result[0].Name != "Sam" || result[0].Total != 2 {
+		t.Fatal("failed to select subquery", err, result)
+	}
+	var users []User
+	young := NewSession().Model(&User{}).Where("Age < ?", 20)
+	if err = s.Table("? AS u", young).Find(&users); err != nil || len(users) != 1 || users[0].Name != "Tom" {
+		t.Fatal("failed to query from subquery", err, users)
+	}
+	count, err := s.Table("? AS u", young).Count()
+	if err != nil || count != 1 {
+		t.Fatal("failed to count from subquery", err, count)
+	}
+}
+
+func TestSession_Compound(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(&User{"Jack", 40}, &User{"Lily", 25})
+	var users []User
+	err := s.Model(&User{}).Where("Age < ?", 20).
+		Union(NewSession().Model(&User{}).Where("Age > ?", 30)).
+		UnionAll(NewSession().Model(&User{}).Where("Name = ?", "Tom")).
+		OrderBy("Age DESC").Limit(2).Find(&users)
+	if err != nil || len(users) != 2 || users[0].Name != "Jack" || users[1].Name != "Tom" {
+		t.Fatal("failed to query union", err, users)
+	}
+	var names []string
+	err = s.Model(&User{}).Where("Age = ?", 25).
+		Intersect(NewSession().Model(&User{}).Select("Name").Where("Name <> ?", "Lily")).
+		Pluck("Name", &names)
+	if err != nil || len(names) != 1 || names[0] != "Sam" {
+		t.Fatal("failed to query intersect", err, names)
+	}
+	count, err := s.Model(&User{}).Where("Age < ?", 20).
+		UnionAll(NewSession().Model(&User{}).Where("Age >= ?", 25)).Count()
+	if err != nil || count != 4 {
+		t.Fatal("failed to count compound query", err, count)
+	}
+	names = nil
+	err = s.Model(&User{}).Select("Name").Except(NewSession().Model(&User{}).Select("Name").Where("Age > ?", 20)).Pluck("Name", &names)
+	if err != nil || len(names) != 1 || names[0] != "Tom" {
+		t.Fatal("failed to query except", err, names)
+	}
+}
+
+type Employee struct {
+	ID        int `geeorm:"PRIMARY KEY"`
+	ManagerID int
+	Name      string
+}
+
+func TestSession_With(t *testing.T) {
+	s := testRecordInit(t)
+	var users []User
+	adults := NewSession().Model(&User{}).Where("Age >= ?", 20)
+	if err := s.With("adults", adults).Table("adults").Where("Name <> ?", "Tom").Find(&users); err != nil || len(users) != 1 || users[0].Name != "Sam" {
+		t.Fatal("failed to query with CTE", err, users)
+	}
+}
+
+func TestSession_WithRecursive(t *testing.T) {
+	s := NewSession().Model(&Employee{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert([]Employee{{1, 0, "CEO"}, {2, 1, "CTO"}, {3, 2, "Dev"}, {4, 3, "Intern"}, {5, 1, "CFO"}, {6, 5, "Accountant"}})
+	chain := NewSession().Raw("SELECT ID, ManagerID, Name FROM Employee WHERE ID = ? "+
+		"UNION ALL SELECT e.ID, e.ManagerID, e.Name FROM Employee e JOIN chain c ON e.ManagerID = c.ID", 2)
+	var employees []Employee
+	err := s.WithRecursive("chain", chain).Table("chain").OrderBy("ID").Find(&employees)
+	if err != nil || len(employees) != 3 || employees[0].Name != "CTO" || employees[2].Name != "Intern" {
+		t.Fatal("failed to query recursive CTE", err, employees)
+	}
+	// CTE 同样可以用于 DELETE
+	affected, err := s.Model(&Employee{}).WithRecursive("chain", chain).Where("ID IN (SELECT ID FROM chain)").Delete()
+	count, _ := s.Count()
+	if err != nil || affected != 3 || count != 3 {
+		t.Fatal("failed to delete with recursive CTE", err, affected, count)
+	}
+}
+
+// lockingDialect 声明支持行锁，用于测试行锁语句的生成
+type lockingDialect struct {
+	dialect.Dialect
+}
+
+func (d *lockingDialect) Supports(feature dialect.Feature) bool {
+	return feature == dialect.RowLocking
+}
+
+func TestSession_Lock(t *testing.T) {
+	s := New(TestDB, &lockingDialect{TestDial}).Model(&User{})
+	sql, _, err := s.Where("Age > ?", 18).ForUpdate().SkipLocked().Limit(1).buildSelect()
+	if err != nil || sql != "SELECT Name, Age FROM User WHERE Age > ? LIMIT ? FOR UPDATE SKIP LOCKED" {
+		t.Fatal("failed to build row lock", err, sql)
+	}
+	s.Clear()
+	if sql, _, _ = s.ForShare().NoWait().buildSelect(); sql != "SELECT Name, Age FROM User FOR SHARE NOWAIT" {
+		t.Fatal("failed to build share lock", sql)
+	}
+
+	// SQLite 不支持行锁，在事务中查询时省略行锁语句
+	s = testRecordInit(t)
+	var users []User
+	err = s.runInTx(func(tx *Session) error {
+		return tx.Where("Age > ?", 18).ForUpdate().NoWait().Find(&users)
+	})
+	if err != nil || len(users) != 1 {
+		t.Fatal("failed to query with row lock on sqlite", err, users)
+	}
+}
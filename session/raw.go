@@ -2,11 +2,14 @@ package session
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"geeorm/clause"
 	"geeorm/dialect"
 	"geeorm/log"
 	"geeorm/schema"
+	"reflect"
 	"strings"
+	"time"
 )
 
 // Session 是会话管理的主要结构，包含会话的所有操作
@@ -18,6 +21,24 @@ type Session struct {
 	refTable *schema.Schema  // refTable 记录 Model 对应的表结构
 	clause   clause.Clause   // clause 是记录 SQL 语句中的各种子句
 	tx       *sql.Tx         // tx 提供事务支持，如果 tx 不为 nil，则执行所有操作都在事务中
+
+	txPerBatch       bool          // txPerBatch 为 true 时，FindInBatches 在独立的事务中处理每一批记录
+	allowDestructive bool          // allowDestructive 为 true 时，MigrateTable 可以删除模型中不存在的列
+	selects          []string      // selects 记录 Select 指定的列，为空时使用所有列
+	selectExprs      []string      // selectExprs 记录 SelectExpr 指定的表达式
+	selectVars       []interface{} // selectVars 记录 SelectExpr 表达式中占位符的值
+	table            string        // table 记录 Table 指定的表名或子查询，为空时使用 Model 对应的表
+	tableVars        []interface{} // tableVars 记录 Table 中占位符的值
+	compounds        []interface{} // compounds 依次记录复合查询的集合运算符和子查询
+	ctes             []interface{} // ctes 依次记录 With 指定的 CTE 名称和子查询
+	recursive        bool          // recursive 为 true 时，CTE 使用 WITH RECURSIVE
+	lock             string        // lock 记录行锁的强度，如 UPDATE、SHARE，为空时不加锁
+	lockWait         string        // lockWait 记录行锁的等待选项，如 SKIP LOCKED、NOWAIT
+	returning        []string      // returning 记录 RETURNING 返回的列，为 nil 时不返回
+	returnTo         interface{}   // returnTo 记录 RETURNING 返回的行填充的目标，为 nil 时填充回传入的结构体
+	omits            []string      // omits 记录 Omit 排除的列
+	distinct         bool          // distinct 为 true 时，查询语句使用 SELECT DISTINCT
+	err              error         // err 记录构建子句时发生的错误，在执行 SQL 语句时返回
 }
 
 // New 返回一个新的会话
@@ -32,6 +53,21 @@ func (s *Session) Clear() {
 	s.sql.Reset()
 	s.sqlVars = nil
 	s.clause = clause.Clause{}
+	s.selects = nil
+	s.selectExprs = nil
+	s.selectVars = nil
+	s.table = ""
+	s.tableVars = nil
+	s.compounds = nil
+	s.ctes = nil
+	s.recursive = false
+	s.lock = ""
+	s.lockWait = ""
+	s.returning = nil
+	s.returnTo = nil
+	s.omits = nil
+	s.distinct = false
+	s.err = nil
 }
 
 // 抽象出一个接口 CommonDB，包含 Query、QueryRow、Exec 三个方法
@@ -61,18 +97,75 @@ func (s *Session) DB() CommonDB {
 //
 // 返回值：
 // *Session: 返回 Session 实例
+//
+// values 中的切片会被展开为对应数量的占位符，例如 Raw("... IN (?)", []int{1, 2}) => "... IN (?, ?)"；
+// values 是唯一的 map[string]interface{} 或结构体参数时，按名称绑定 sql 中的 @name 和 :name 命名参数；
+// values 中未执行的 *Session 会作为子查询内嵌到 sql 中
 func (s *Session) Raw(sql string, values ...interface{}) *Session {
+	sql, values = s.bindNamed(sql, values)
+	sql, values = clause.ExpandVars(sql, s.subqueries(values))
 	s.sql.WriteString(sql)
 	s.sql.WriteString(" ")
 	s.sqlVars = append(s.sqlVars, values...)
 	return s
 }
 
+// prepare 写入已经由子句构建好的 SQL 语句，参数已经展开，不再处理切片和命名参数
+func (s *Session) prepare(sql string, vars ...interface{}) *Session {
+	s.sql.WriteString(sql)
+	s.sql.WriteString(" ")
+	s.sqlVars = append(s.sqlVars, vars...)
+	return s
+}
+
+// bindNamed 如果 values 是唯一的 map[string]interface{} 或结构体参数，
+// 则将 sql 中的命名参数替换为占位符，结构体按表结构中的列名取值
+func (s *Session) bindNamed(sql string, values []interface{}) (string, []interface{}) {
+	if len(values) != 1 {
+		return sql, values
+	}
+	var params map[string]interface{}
+	switch v := values[0].(type) {
+	case map[string]interface{}:
+		params = v
+	case driver.Valuer, time.Time, *time.Time, *Session:
+		return sql, values
+	default:
+		if reflect.Indirect(reflect.ValueOf(v)).Kind() != reflect.Struct {
+			return sql, values
+		}
+		table := schema.Parse(v, s.dialect)
+		params = make(map[string]interface{}, len(table.FieldNames))
+		for i, value := range table.RecordValues(v) {
+			params[table.FieldNames[i]] = value
+		}
+	}
+	named, vars, err := clause.Named(sql, params)
+	if err != nil {
+		s.err = err
+		return sql, nil
+	}
+	return named, vars
+}
+
+// statement 返回要执行的 SQL 语句，其中的 ? 占位符按方言的 BindVar 转换为数据库的占位符
+func (s *Session) statement() string {
+	if s.dialect.BindVar(1) == "?" {
+		return s.sql.String()
+	}
+	return clause.Rebind(s.sql.String(), s.dialect.BindVar)
+}
+
 // Exec 执行 s.sql 这条 SQL 语句，参数为 s.sqlVars
 func (s *Session) Exec() (result sql.Result, err error) {
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	if result, err = s.DB().Exec(s.sql.String(), s.sqlVars...); err != nil {
+	if err = s.err; err != nil {
+		log.Error(err)
+		return
+	}
+	query := s.statement()
+	log.Info(query, s.sqlVars)
+	if result, err = s.DB().Exec(query, s.sqlVars...); err != nil {
 		log.Error(err)
 	}
 	return
@@ -80,23 +173,55 @@ func (s *Session) Exec() (result sql.Result, err error) {
 
 // QueryRow 执行 s.sql 这条 SQL 语句，参数为 s.sqlVars
 // 并且返回一行记录，该记录是 *sql.Row 类型
+//
+// *sql.Row 不能携带构建语句时记录的错误，该错误只会被记录到日志中；需要得到该错误时使用 QueryRows
 func (s *Session) QueryRow() *sql.Row {
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	return s.db.QueryRow(s.sql.String(), s.sqlVars...)
+	if s.err != nil {
+		log.Error(s.err)
+	}
+	query := s.statement()
+	log.Info(query, s.sqlVars)
+	return s.DB().QueryRow(query, s.sqlVars...)
 }
 
 // QueryRows 执行 s.sql 这条 SQL 语句，参数为 s.sqlVars
 // 并且返回多行记录，该记录是 *sql.Rows 类型
 func (s *Session) QueryRows() (rows *sql.Rows, err error) {
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	if rows, err = s.DB().Query(s.sql.String(), s.sqlVars...); err != nil {
+	if err = s.err; err != nil {
+		log.Error(err)
+		return
+	}
+	query := s.statement()
+	log.Info(query, s.sqlVars)
+	if rows, err = s.DB().Query(query, s.sqlVars...); err != nil {
 		log.Error(err)
 	}
 	return
 }
 
+// queryValue 执行 s.sql 这条 SQL 语句，并将第一行记录的各列依次填充到 dest 中
+//
+// 与 QueryRow 不同，构建语句时记录的错误会在执行之前返回；没有记录时返回 sql.ErrNoRows
+func (s *Session) queryValue(dest ...interface{}) error {
+	rows, err := s.QueryRows()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
 // Exec 方法用于执行不需要返回行的 SQL 语句，例如 INSERT、DELETE、UPDATE 等
 // 返回值 result 是 sql.Result 类型，用于返回执行结果（受影响的行数等）
 // QueryRow 方法用于执行需要返回单行结果的 SQL 查询，例如 SELECT 查询
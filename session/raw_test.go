@@ -2,7 +2,9 @@ package session
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"geeorm/dialect"
@@ -17,6 +19,7 @@ var (
 
 func TestMain(m *testing.M) {
 	TestDB, _ = sql.Open("sqlite3", "../gee.db")
+	TestDial, _ = dialect.Detect(TestDial, TestDB)
 	code := m.Run()
 	_ = TestDB.Close()
 	os.Exit(code)
@@ -45,3 +48,54 @@ func TestSession_QueryRows(t *testing.T) {
 		t.Fatal("failed to query db", err)
 	}
 }
+
+func TestSession_RawNamed(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text, Age integer);").Exec()
+	_, _ = s.Raw("INSERT INTO User(Name, Age) VALUES (:Name, :Age)", &User{Name: "Tom", Age: 18}).Exec()
+	_, _ = s.Raw("INSERT INTO User(Name, Age) VALUES (@name, @age)", map[string]interface{}{"name": "Sam", "age": 25}).Exec()
+	var count int
+	err := s.Raw("SELECT count(*) FROM User WHERE Age >= @min OR Name IN (@names) OR Age = @min",
+		map[string]interface{}{"min": 20, "names": []string{"Tom"}}).Scan(&count)
+	if err != nil || count != 2 {
+		t.Fatal("failed to bind named parameters", err, count)
+	}
+	var names []string
+	err = s.Model(&User{}).Where("Age < @age", map[string]interface{}{"age": 20}).Pluck("Name", &names)
+	if err != nil || len(names) != 1 || names[0] != "Tom" {
+		t.Fatal("failed to bind named parameters in Where", err, names)
+	}
+	if _, err = s.Raw("SELECT * FROM User WHERE Age > @age", map[string]interface{}{}).Exec(); err == nil {
+		t.Fatal("expect error on missing named parameter")
+	}
+	if err = s.Raw("SELECT count(*) FROM User WHERE Age > @age", map[string]interface{}{}).queryValue(&count); err == nil ||
+		!strings.Contains(err.Error(), "age") {
+		t.Fatal("expect named parameter error before executing the statement", err)
+	}
+	if _, err = s.Model(&User{}).Where("Age > @age", map[string]interface{}{}).Count(); err == nil {
+		t.Fatal("expect error on missing named parameter in Count")
+	}
+}
+
+// numberedDialect 使用 SQLite 的 ?NNN 编号占位符，用于测试执行前按方言转换占位符
+type numberedDialect struct {
+	dialect.Dialect
+}
+
+func (d *numberedDialect) BindVar(i int) string {
+	return fmt.Sprintf("?%d", i)
+}
+
+func TestSession_BindVar(t *testing.T) {
+	s := New(TestDB, &numberedDialect{TestDial})
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text, Age integer);").Exec()
+	_, _ = s.Insert(&User{Name: "Tom", Age: 18}, &User{Name: "Sam", Age: 25})
+	var names []string
+	err := s.Raw("SELECT Name FROM User WHERE Age > @min AND Name <> '?' AND Name IN (@names) ORDER BY Name",
+		map[string]interface{}{"min": 10, "names": []string{"Sam", "Jack"}}).Scan(&names)
+	if err != nil || len(names) != 1 || names[0] != "Sam" {
+		t.Fatal("failed to execute with dialect placeholders", err, names)
+	}
+}
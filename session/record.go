@@ -2,40 +2,122 @@ package session
 
 import (
 	"errors"
+	"fmt"
 	"geeorm/clause"
+	"geeorm/schema"
 	"reflect"
+	"slices"
+	"sort"
 )
 
 // Insert 插入记录到数据库中
 //
 // 参数:
-// values: 要插入的记录
+// values: 要插入的记录，可以是结构体指针，也可以是结构体（指针）的切片
 //
 // 返回值:
 // int64: 受影响的行数
 // error: 如果插入过程中发生错误，返回错误信息
 //
+// 只写入 Select 指定的列，并排除 Omit 的列和生成列。
+// 所有记录的占位符数量超过方言的 MaxPlaceholders 时，按该上限拆分成多条 INSERT 语句，
+// 并在同一个事务中执行，返回所有语句受影响的行数之和
+//
 // 示例:
 // User1 := &User{"Tom", 18}、User2 := &User{"Sam", 25}
 // affected, err := s.Insert(User1, User2)
+// affected, err := s.Insert([]User{{"Tom", 18}, {"Sam", 25}})
 func (s *Session) Insert(values ...interface{}) (int64, error) {
-	recordValues := make([]interface{}, 0)
-	for _, value := range values {
-		// tables.Name 是 User，tables.FieldNames 是 [Name, Age]
-		tables := s.Model(value).RefTable()
-		// INSERT INTO $tableName ($fields)
-		s.clause.Set(clause.INSERT, tables.Name, tables.FieldNames)
-		recordValues = append(recordValues, tables.RecordValues(value))
+	records := flattenRecords(values)
+	if len(records) == 0 {
+		s.Clear()
+		return 0, nil
+	}
+	// tables.Name 是 User，tables.FieldNames 是 [Name, Age]
+	table := s.Model(records[0]).RefTable()
+	columns, err := s.columns(table)
+	if err != nil {
+		s.Clear()
+		return 0, err
+	}
+	if columns = slices.DeleteFunc(columns, func(name string) bool { return !writable(table, name) }); len(columns) == 0 {
+		s.Clear()
+		return 0, fmt.Errorf("no writable columns in table %s", table.Name)
+	}
+	// 每条 INSERT 语句最多能容纳的记录数
+	size := s.dialect.MaxPlaceholders() / len(columns)
+	if size < 1 {
+		size = 1
+	}
+	if len(records) <= size {
+		return s.insert(records, columns)
 	}
+	returning, returnTo := s.returning, s.returnTo
+	s.Clear()
+	var total int64
+	err = s.runInTx(func(tx *Session) error {
+		for start := 0; start < len(records); start += size {
+			tx.returning, tx.returnTo = returning, returnTo
+			affected, err := tx.insert(records[start:min(start+size, len(records))], columns)
+			if err != nil {
+				return err
+			}
+			total += affected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// insert 用一条 INSERT 语句插入 records 的 columns 列
+func (s *Session) insert(records []interface{}, columns []string) (int64, error) {
+	table := s.RefTable()
+	recordValues := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		s.CallMethod(BeforeInsert, record)
+		recordValues = append(recordValues, table.RecordValues(record, columns...))
+	}
+	// INSERT INTO $tableName ($fields)
+	s.clause.Set(clause.INSERT, table.Name, columns)
 	// VALUES (?, ?), (?, ?)
 	s.clause.Set(clause.VALUES, recordValues...)
-	sql, vars := s.clause.Build(clause.INSERT, clause.VALUES)
 	// INSERT INTO $tableName ($fields) VALUES (?, ?), (?, ?)
-	result, err := s.Raw(sql, vars...).Exec()
+	affected, err := s.execWrite(records, clause.InsertStatement)
 	if err != nil {
 		return 0, err
 	}
-	return result.RowsAffected()
+	for _, record := range records {
+		s.CallMethod(AfterInsert, record)
+	}
+	return affected, nil
+}
+
+// flattenRecords 将参数中的切片和数组展开为单条记录
+//
+// 切片中的结构体元素会取地址，以便调用指针接收者上的钩子函数
+func flattenRecords(values []interface{}) []interface{} {
+	records := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		v := reflect.ValueOf(value)
+		if v.Kind() == reflect.Ptr && (v.Elem().Kind() == reflect.Slice || v.Elem().Kind() == reflect.Array) {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			records = append(records, value)
+			continue
+		}
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			if elem.Kind() != reflect.Ptr && elem.CanAddr() {
+				elem = elem.Addr()
+			}
+			records = append(records, elem.Interface())
+		}
+	}
+	return records
 }
 
 // Find 查找记录并填充到 values 中
@@ -46,66 +128,172 @@ func (s *Session) Insert(values ...interface{}) (int64, error) {
 // 返回值:
 // error: 如果查找过程中发生错误，返回错误信息
 //
+// 只查询 Select 指定的列，并排除 Omit 的列，未查询的字段保持零值；
+// Select 中的表达式按别名填充到同名字段，设置了 Table 时从该表（或子查询）中查询
+//
 // 示例:
 // var users []User
 // err := s.Find(&users)
+// err := s.Select("Name").Find(&users)
 func (s *Session) Find(values interface{}) error {
 	// 将 values 转换为 reflect.Value 类型并获取其指针指向的值，即 []User
 	destValue := reflect.Indirect(reflect.ValueOf(values))
 	// 获取 []User 中的元素类型 User，即 destType 是 User 类型
 	destType := destValue.Type().Elem()
 	// 获取 User 对应的表结构
-	table := s.Model(reflect.New(destType).Elem().Interface()).RefTable()
+	s.Model(reflect.New(destType).Elem().Interface())
+	s.CallMethod(BeforeQuery, nil)
 	// SELECT $fields FROM $tableName，即 SELECT Name, Age FROM users
-	s.clause.Set(clause.SELECT, table.Name, table.FieldNames)
-	sql, vars := s.clause.Build(clause.SELECT, clause.WHERE, clause.ORDERBY, clause.LIMIT)
+	sql, vars, err := s.buildSelect()
+	if err != nil {
+		s.Clear()
+		return err
+	}
 	// 执行代码
-	rows, err := s.Raw(sql, vars...).QueryRows()
+	rows, err := s.prepare(sql, vars...).QueryRows()
 	if err != nil {
 		return err
 	}
-	// 遍历查询结果并将结果填充到 values 中
+	defer func() { _ = rows.Close() }()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	// 遍历查询结果并按列名将结果填充到 values 中
 	for rows.Next() {
 		dest := reflect.New(destType).Elem()
-		var values []interface{}
-		for _, name := range table.FieldNames {
-			values = append(values, dest.FieldByName(name).Addr().Interface())
-		}
-		if err := rows.Scan(values...); err != nil {
+		if err := scanRow(rows, columns, dest); err != nil {
 			return err
 		}
+		s.CallMethod(AfterQuery, dest.Addr().Interface())
 		destValue.Set(reflect.Append(destValue, dest))
 	}
-	return rows.Close()
+	return rows.Err()
 }
 
 // Update 更新记录
 //
 // 参数:
-// kv: 要更新的键值对
+// kv: 要更新的键值对，或者一个结构体（指针）
 //
 // 返回值:
 // int64: 受影响的行数
 // error: 如果更新过程中发生错误，返回错误信息
 //
+// 传入结构体时，默认只更新非零值的字段；调用了 Select 时更新选中的列，包括零值。
+// 两种形式都会排除 Omit 的列和生成列，传入键值对时同样只保留 Select 指定的列。
+// SET 中的列按表结构中字段的顺序排列，因此生成的 SQL 语句和参数顺序是固定的
+//
 // 示例:
 // affected, err := s.Update("Age", 30)
 // affected, err := s.Update(map[string]interface{}{"Age": 30, "Name": "Tom"})
+// affected, err := s.Update("Age", clause.Expr("Age + ?", 1))
+// affected, err := s.Update(&User{Age: 30})                // 只更新 Age
+// affected, err := s.Select("Name", "Age").Update(&User{}) // 将 Name 和 Age 更新为零值
 func (s *Session) Update(kv ...interface{}) (int64, error) {
 	m, ok := kv[0].(map[string]interface{})
-	if !ok {
+	if !ok && reflect.Indirect(reflect.ValueOf(kv[0])).Kind() == reflect.Struct {
+		var err error
+		if m, err = s.structValues(kv[0]); err != nil {
+			s.Clear()
+			return 0, err
+		}
+	} else if !ok {
 		m = make(map[string]interface{})
 		for i := 0; i < len(kv); i += 2 {
 			m[kv[i].(string)] = kv[i+1]
 		}
 	}
-	s.clause.Set(clause.UPDATE, s.RefTable().Name, m)
-	sql, vars := s.clause.Build(clause.UPDATE, clause.WHERE)
-	result, err := s.Raw(sql, vars...).Exec()
+	table := s.RefTable()
+	// 筛选到新的 map 中，不修改调用者传入的 map
+	values := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if s.selected(key) && writable(table, key) {
+			values[key] = value
+		}
+	}
+	if len(values) == 0 {
+		s.Clear()
+		return 0, errors.New("no columns to update")
+	}
+	s.clause.Set(clause.UPDATE, table.Name, values, updateOrder(table, values))
+	var targets []interface{}
+	if reflect.ValueOf(kv[0]).Kind() == reflect.Ptr {
+		targets = kv[:1]
+	}
+	return s.execWrite(targets, clause.UpdateStatement)
+}
+
+// structValues 返回结构体 value 中要更新的列和值
+//
+// 调用了 Select 时返回选中的列，否则返回非零值的列，都会排除 Omit 的列；
+// 主键列只有通过 Select 明确选中时才会被更新，避免 Where 匹配的多条记录的主键都被改写
+func (s *Session) structValues(value interface{}) (map[string]interface{}, error) {
+	table := s.Model(value).RefTable()
+	columns, err := s.columns(table)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	explicit := len(s.selects) > 0
+	destValue := reflect.Indirect(reflect.ValueOf(value))
+	m := make(map[string]interface{})
+	for _, column := range columns {
+		f := table.GetField(column)
+		if f == table.PrimaryField && !slices.Contains(s.selects, column) {
+			continue
+		}
+		field := destValue.FieldByName(f.FieldName)
+		if writable(table, column) && (explicit || !field.IsZero()) {
+			m[column] = field.Interface()
+		}
+	}
+	return m, nil
+}
+
+// updateOrder 返回 m 中的列按 table 中字段顺序排列的结果，不属于 table 的列按字典序排在最后
+func updateOrder(table *schema.Schema, m map[string]interface{}) []string {
+	order := make([]string, 0, len(m))
+	for _, name := range table.FieldNames {
+		if _, ok := m[name]; ok {
+			order = append(order, name)
+		}
+	}
+	var rest []string
+	for key := range m {
+		if table.GetField(key) == nil {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(order, rest...)
+}
+
+// Increment 将列 column 的值原子地增加 n，返回受影响的行数
+//
+// 参数:
+// column: 要增加的列
+// n: 增加的值
+//
+// 直接生成 SET column = column + ?，不需要先读取再写回，因此并发更新时不会丢失
+//
+// 示例:
+// affected, err := s.Model(&Post{}).Where("ID = ?", 1).Increment("Views", 1)
+func (s *Session) Increment(column string, n interface{}) (int64, error) {
+	return s.step(column, "+", n)
+}
+
+// Decrement 将列 column 的值原子地减少 n，返回受影响的行数
+func (s *Session) Decrement(column string, n interface{}) (int64, error) {
+	return s.step(column, "-", n)
+}
+
+// step 将列 column 的值按运算符 op 原子地更新
+func (s *Session) step(column, op string, n interface{}) (int64, error) {
+	if table := s.RefTable(); table != nil && table.GetField(column) == nil {
+		s.Clear()
+		return 0, fmt.Errorf("unknown column %s in table %s", column, table.Name)
 	}
-	return result.RowsAffected()
+	return s.Update(column, clause.Expr(fmt.Sprintf("%s %s ?", column, op), n))
 }
 
 // Delete 删除记录
@@ -114,32 +302,164 @@ func (s *Session) Update(kv ...interface{}) (int64, error) {
 // int64: 受影响的行数
 func (s *Session) Delete() (int64, error) {
 	s.clause.Set(clause.DELETE, s.RefTable().Name)
-	sql, vars := s.clause.Build(clause.DELETE, clause.WHERE)
-	result, err := s.Raw(sql, vars...).Exec()
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected()
+	return s.execWrite(nil, clause.DeleteStatement)
 }
 
 // Count 返回记录总数
+//
+// 设置了 Union 等复合查询时，返回整个复合查询结果的行数，即 SELECT count(*) FROM (复合查询)
 func (s *Session) Count() (int64, error) {
-	s.clause.Set(clause.COUNT, s.RefTable().Name)
-	sql, vars := s.clause.Build(clause.COUNT, clause.WHERE)
-	row := s.Raw(sql, vars...).QueryRow()
+	if err := s.err; err != nil {
+		s.Clear()
+		return 0, err
+	}
 	var count int64
-	if err := row.Scan(&count); err != nil {
+	if len(s.compounds) > 0 {
+		sql, vars, err := s.buildSelect()
+		if err != nil {
+			s.Clear()
+			return 0, err
+		}
+		if err = s.prepare(fmt.Sprintf("SELECT count(*) FROM (%s)", sql), vars...).queryValue(&count); err != nil {
+			return 0, err
+		}
+		return count, nil
+	}
+	tableName := s.table
+	if tableName == "" {
+		tableName = s.RefTable().Name
+	}
+	s.clause.Set(clause.COUNT, append([]interface{}{tableName}, s.tableVars...)...)
+	sql, vars, err := s.clause.BuildStatement(clause.CountStatement, s.dialect)
+	if err != nil {
+		s.Clear()
+		return 0, err
+	}
+	if err = s.prepare(sql, vars...).queryValue(&count); err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
 // Where 添加 WHERE 子句，返回值是 *Session 可以链式调用
-func (s *Session) Where(desc string, args ...interface{}) *Session {
-	s.clause.Set(clause.WHERE, append([]interface{}{desc}, args...)...)
+//
+// 参数:
+// query: 条件语句字符串，或者 clause.Expression 表达式
+// args: 条件语句中占位符的值，切片会被展开为多个占位符；query 为表达式时忽略。
+// args 是唯一的 map[string]interface{} 或结构体参数时，按名称绑定 @name 和 :name 命名参数
+//
+// query 为表达式时，列名按当前 Model 的表结构校验并加上引号，因此需要先调用 Model；
+// 校验失败的错误在执行语句时返回
+//
+// 示例:
+// s.Where("Name = ?", "Tom")
+// s.Where("Name IN (?)", []string{"Tom", "Sam"})
+// s.Where("Age > @age OR Name = @name", map[string]interface{}{"age": 18, "name": "Tom"})
+// s.Where("Age > ?", NewSession().Model(&User{}).SelectExpr("AVG(Age)"))
+// s.Model(&User{}).Where(clause.Or(clause.Eq{"Name": "Tom"}, clause.Gt{"Age": 18}))
+func (s *Session) Where(query interface{}, args ...interface{}) *Session {
+	return s.condition(clause.WHERE, query, args...)
+}
+
+// condition 将条件语句或表达式设置为 typ 类型的子句
+func (s *Session) condition(typ clause.Type, query interface{}, args ...interface{}) *Session {
+	switch q := query.(type) {
+	case string:
+		q, args = s.bindNamed(q, args)
+		s.clause.Set(typ, append([]interface{}{q}, s.subqueries(args)...)...)
+	case clause.Expression:
+		desc, vars, err := q.Build(s.refTable, s.dialect)
+		if err != nil {
+			s.err = err
+			return s
+		}
+		// 空表达式不添加任何条件
+		if desc == "" {
+			return s
+		}
+		s.clause.Set(typ, append([]interface{}{desc}, vars...)...)
+	default:
+		s.err = fmt.Errorf("unsupported condition type %T", query)
+	}
+	return s
+}
+
+// Select 指定查询、插入和更新时使用的列，返回值是 *Session 可以链式调用
+//
+// 示例:
+// s.Select("Name", "Age")
+func (s *Session) Select(columns ...string) *Session {
+	s.selects = append(s.selects, columns...)
+	return s
+}
+
+// SelectExpr 添加查询时使用的表达式，返回值是 *Session 可以链式调用
+//
+// 参数:
+// query: 包含占位符的表达式，例如 AVG(Age) 或 ? AS Total
+// args: 占位符的值，可以是未执行的 *Session 子查询
+//
+// 表达式只作用于查询语句，排在 Select 指定的列之后；只调用了 SelectExpr 时查询语句只包含表达式
+//
+// 示例:
+// s.Select("Name").SelectExpr("? AS Total", sub)
+// NewSession().Model(&User{}).SelectExpr("AVG(Age)")
+func (s *Session) SelectExpr(query string, args ...interface{}) *Session {
+	sql, vars := clause.ExpandVars(query, s.subqueries(args))
+	s.selectExprs = append(s.selectExprs, sql)
+	s.selectVars = append(s.selectVars, vars...)
+	return s
+}
+
+// Omit 指定查询、插入和更新时排除的列，返回值是 *Session 可以链式调用
+func (s *Session) Omit(columns ...string) *Session {
+	s.omits = append(s.omits, columns...)
 	return s
 }
 
+// Distinct 设置查询时对结果去重，即 SELECT DISTINCT，返回值是 *Session 可以链式调用
+func (s *Session) Distinct() *Session {
+	s.distinct = true
+	return s
+}
+
+// columns 返回 table 中经过 Select 和 Omit 筛选后的列名
+//
+// 没有调用 Select 时使用所有列，列名不存在或者筛选后没有剩余列时返回错误
+func (s *Session) columns(table *schema.Schema) ([]string, error) {
+	names := table.FieldNames
+	if len(s.selects) > 0 {
+		names = s.selects
+	}
+	var columns []string
+	for _, name := range names {
+		if table.GetField(name) == nil {
+			return nil, fmt.Errorf("unknown column %s in table %s", name, table.Name)
+		}
+		if s.selected(name) {
+			columns = append(columns, name)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns selected in table %s", table.Name)
+	}
+	return columns, nil
+}
+
+// writable 判断表 table 中的列 name 能否写入，生成列的值由数据库计算，不能写入
+func writable(table *schema.Schema, name string) bool {
+	f := table.GetField(name)
+	return f == nil || f.Generated == ""
+}
+
+// selected 判断列 name 是否被 Select 选中且没有被 Omit 排除
+func (s *Session) selected(name string) bool {
+	if slices.Contains(s.omits, name) {
+		return false
+	}
+	return len(s.selects) == 0 || slices.Contains(s.selects, name)
+}
+
 // Limit 添加 LIMIT 子句，返回值是 *Session 可以链式调用
 func (s *Session) Limit(num int) *Session {
 	s.clause.Set(clause.LIMIT, num)
@@ -152,6 +472,19 @@ func (s *Session) OrderBy(desc string) *Session {
 	return s
 }
 
+// Clause 设置通过 clause.Register 注册的自定义子句，返回值是 *Session 可以链式调用
+//
+// 参数:
+// typ: 子句类型，需要在语句的构建顺序中才会被构建
+// vars: 传给子句生成器的参数
+//
+// 示例:
+// s.Model(&User{}).Clause(USEINDEX, "idx_age").Where("Age > ?", 18).Find(&users)
+func (s *Session) Clause(typ clause.Type, vars ...interface{}) *Session {
+	s.clause.Set(typ, vars...)
+	return s
+}
+
 func (s *Session) First(value interface{}) error {
 	dest := reflect.Indirect(reflect.ValueOf(value))
 	destSlice := reflect.New(reflect.SliceOf(dest.Type())).Elem()
@@ -1,6 +1,12 @@
 package session
 
-import "testing"
+import (
+	"database/sql"
+	"testing"
+
+	"geeorm/clause"
+	"geeorm/dialect"
+)
 
 var (
 	user1 = &User{Name: "Tom", Age: 18}
@@ -28,6 +34,42 @@ func TestSession_Insert(t *testing.T) {
 	}
 }
 
+// smallDialect 限制占位符数量，用于测试批量插入的拆分
+type smallDialect struct {
+	dialect.Dialect
+}
+
+func (d *smallDialect) MaxPlaceholders() int {
+	return 5
+}
+
+func TestSession_InsertSlice(t *testing.T) {
+	s := testRecordInit(t)
+	affected, err := s.Insert([]User{{"Jack", 25}, {"Lily", 22}}, &[]*User{{"Lucy", 21}})
+	count, _ := s.Count()
+	if err != nil || affected != 3 || count != 5 {
+		t.Fatal("failed to insert slice", err, affected, count)
+	}
+}
+
+func TestSession_InsertChunks(t *testing.T) {
+	s := New(TestDB, &smallDialect{TestDial}).Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	users := []User{{"A", 1}, {"B", 2}, {"C", 3}, {"D", 4}, {"E", 5}}
+	affected, err := s.Insert(users)
+	count, _ := s.Count()
+	if err != nil || affected != 5 || count != 5 {
+		t.Fatal("failed to insert in chunks", err, affected, count)
+	}
+	// 后面的分块失败时，整批插入回滚
+	affected, err = s.Insert([]User{{"F", 6}, {"G", 7}, {"A", 1}})
+	count, _ = s.Count()
+	if err == nil || affected != 0 || count != 5 {
+		t.Fatal("failed to rollback chunked insert", err, affected, count)
+	}
+}
+
 func TestSession_Find(t *testing.T) {
 	s := testRecordInit(t)
 	var users []User
@@ -36,6 +78,157 @@ func TestSession_Find(t *testing.T) {
 	}
 }
 
+func TestSession_Select(t *testing.T) {
+	s := testRecordInit(t)
+	var users []User
+	if err := s.Select("Name").Find(&users); err != nil || len(users) != 2 || users[0].Name == "" || users[0].Age != 0 {
+		t.Fatal("failed to query selected columns", err, users)
+	}
+	users = nil
+	if err := s.Omit("Name").Find(&users); err != nil || len(users) != 2 || users[0].Name != "" || users[0].Age == 0 {
+		t.Fatal("failed to query omitted columns", err, users)
+	}
+	if err := s.Select("Password").Find(&users); err == nil {
+		t.Fatal("expect error on unknown column")
+	}
+	// Select 只接受列名，表达式需要通过 SelectExpr 指定
+	if err := s.Select("AVG(Age)").Find(&users); err == nil {
+		t.Fatal("expect error on expression passed to Select")
+	}
+	var avg float64
+	if err := s.Model(&User{}).SelectExpr("AVG(Age)").Scan(&avg); err != nil || avg != 21.5 {
+		t.Fatal("failed to select expression", err, avg)
+	}
+}
+
+func TestSession_Distinct(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	var users []User
+	if err := s.Select("Age").Distinct().Find(&users); err != nil || len(users) != 2 {
+		t.Fatal("failed to query distinct", err, users)
+	}
+}
+
+func TestSession_InsertOmit(t *testing.T) {
+	s := testRecordInit(t)
+	if _, err := s.Omit("Age").Insert(&User{Name: "Lily", Age: 20}); err != nil {
+		t.Fatal("failed to insert with omit", err)
+	}
+	if count, err := s.Where("Name = ? AND Age IS NULL", "Lily").Count(); err != nil || count != 1 {
+		t.Fatal("failed to omit column on insert", err, count)
+	}
+}
+
+func TestSession_UpdateOmitMap(t *testing.T) {
+	s := testRecordInit(t)
+	m := map[string]interface{}{"Age": 30, "Name": "Jerry"}
+	affected, err := s.Omit("Name").Where("Name = ?", "Tom").Update(m)
+	if err != nil || affected != 1 {
+		t.Fatal("failed to update map with omit", err, affected)
+	}
+	if len(m) != 2 || m["Name"] != "Jerry" {
+		t.Fatal("Update should not modify the caller's map", m)
+	}
+	if count, _ := s.Where("Name = ? AND Age = ?", "Tom", 30).Count(); count != 1 {
+		t.Fatal("failed to omit column on update", count)
+	}
+}
+
+func TestSession_UpdateStruct(t *testing.T) {
+	s := testRecordInit(t)
+	affected, err := s.Select("Age").Where("Name = ?", "Tom").Update(&User{Name: "Jerry", Age: 30})
+	u := &User{}
+	_ = s.Where("Name = ?", "Tom").First(u)
+	if err != nil || affected != 1 || u.Age != 30 {
+		t.Fatal("failed to update struct", err, affected, u)
+	}
+}
+
+func TestSession_UpdateNonZero(t *testing.T) {
+	s := testRecordInit(t)
+	affected, err := s.Where("Name = ?", "Tom").Update(&User{Age: 30})
+	u := &User{}
+	_ = s.Where("Name = ?", "Tom").First(u)
+	if err != nil || affected != 1 || u.Age != 30 {
+		t.Fatal("failed to update non-zero fields", err, affected, u)
+	}
+	affected, err = s.Select("Age").Where("Name = ?", "Tom").Update(&User{})
+	_ = s.Where("Name = ?", "Tom").First(u)
+	if err != nil || affected != 1 || u.Age != 0 {
+		t.Fatal("failed to update selected zero fields", err, affected, u)
+	}
+	if _, err = s.Where("Name = ?", "Tom").Update(&User{}); err == nil {
+		t.Fatal("expect error when no columns to update")
+	}
+	// 主键不在 SET 中，除非通过 Select 明确选中
+	affected, err = s.Where("Name = ?", "Tom").Update(&User{Name: "Jerry", Age: 31})
+	_ = s.Where("Name = ?", "Tom").First(u)
+	if err != nil || affected != 1 || u.Age != 31 {
+		t.Fatal("primary key should not be updated", err, affected, u)
+	}
+	affected, err = s.Select("Name").Where("Name = ?", "Tom").Update(&User{Name: "Jerry"})
+	if count, _ := s.Where("Name = ?", "Jerry").Count(); err != nil || affected != 1 || count != 1 {
+		t.Fatal("failed to update selected primary key", err, affected, count)
+	}
+}
+
+func TestSession_Increment(t *testing.T) {
+	s := testRecordInit(t)
+	affected, err := s.Where("Name = ?", "Tom").Increment("Age", 5)
+	u := &User{}
+	_ = s.Where("Name = ?", "Tom").First(u)
+	if err != nil || affected != 1 || u.Age != 23 {
+		t.Fatal("failed to increment", err, affected, u)
+	}
+	_, err = s.Model(&User{}).Decrement("Age", 3)
+	_ = s.Where("Name = ?", "Sam").First(u)
+	if err != nil || u.Age != 22 {
+		t.Fatal("failed to decrement", err, u)
+	}
+	_, err = s.Model(&User{}).Where("Name = ?", "Sam").Update("Age", clause.Expr("Age * ?", 2))
+	_ = s.Where("Name = ?", "Sam").First(u)
+	if err != nil || u.Age != 44 {
+		t.Fatal("failed to update with expression", err, u)
+	}
+	if _, err = s.Model(&User{}).Increment("Views", 1); err == nil {
+		t.Fatal("expect error on unknown column")
+	}
+}
+
+func TestSession_WhereExpression(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	var users []User
+	err := s.Model(&User{}).Where(clause.And(clause.Gte{"Age": 20}, clause.Neq{"Name": "Sam"})).Find(&users)
+	if err != nil || len(users) != 1 || users[0].Name != "Jack" {
+		t.Fatal("failed to query with expression", err, users)
+	}
+	if err = s.Model(&User{}).Where(clause.Eq{"Nmae": "Tom"}).Find(&users); err == nil {
+		t.Fatal("expect error on unknown column")
+	}
+}
+
+func TestSession_WhereIn(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	var users []User
+	if err := s.Where("Name IN (?)", []string{"Tom", "Jack"}).Find(&users); err != nil || len(users) != 2 {
+		t.Fatal("failed to query with slice argument", err, users)
+	}
+	users = nil
+	if err := s.Where("Name IN (?)", []string{}).Find(&users); err != nil || len(users) != 0 {
+		t.Fatal("failed to query with empty slice argument", err, users)
+	}
+	if err := s.Where("Name NOT IN (?)", []string{}).Find(&users); err != nil || len(users) != 3 {
+		t.Fatal("empty NOT IN should match all records", err, users)
+	}
+	var count int
+	if err := s.Raw("SELECT count(*) FROM User WHERE Age IN (?)", []int{18, 25}).Scan(&count); err != nil || count != 3 {
+		t.Fatal("failed to expand slice argument in Raw", err, count)
+	}
+}
+
 func TestSession_Limit(t *testing.T) {
 	s := testRecordInit(t)
 	var users []User
@@ -65,3 +258,65 @@ func TestSession_DeleteAndCount(t *testing.T) {
 		t.Fatal("failed to delete or count")
 	}
 }
+
+type Contact struct {
+	Name  string `geeorm:"PRIMARY KEY"`
+	Phone *string
+	Age   sql.Null[int]
+	Email sql.NullString
+}
+
+func TestSession_NullableFields(t *testing.T) {
+	s := NewSession().Model(&Contact{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table", err)
+	}
+	if diff, err := s.DiffTable(); err != nil || len(diff.ChangedColumns) != 0 {
+		t.Fatal("nullable fields should map to plain column types", diff, err)
+	}
+	phone := "123"
+	_, err := s.Insert(&Contact{Name: "Tom"}, &Contact{Name: "Sam", Phone: &phone,
+		Age: sql.Null[int]{V: 18, Valid: true}, Email: sql.NullString{String: "sam@example.com", Valid: true}})
+	if err != nil {
+		t.Fatal("failed to insert nullable fields", err)
+	}
+	var contacts []Contact
+	if err = s.OrderBy("Name").Find(&contacts); err != nil || len(contacts) != 2 {
+		t.Fatal("failed to query nullable fields", err)
+	}
+	sam, tom := contacts[0], contacts[1]
+	if sam.Phone == nil || *sam.Phone != "123" || sam.Age.V != 18 || sam.Email.String != "sam@example.com" ||
+		tom.Phone != nil || tom.Age.Valid || tom.Email.Valid {
+		t.Fatal("failed to scan nullable fields", contacts)
+	}
+}
+
+type Customer struct {
+	ID        int    `geeorm:"PRIMARY KEY;column:id"`
+	FullName  string `geeorm:"column:full_name"`
+	CreatedAt int64  `geeorm:"column:created_at"`
+}
+
+func (Customer) TableName() string {
+	return "customers"
+}
+
+func TestSession_ColumnName(t *testing.T) {
+	s := NewSession().Model(&Customer{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil || s.RefTable().Name != "customers" {
+		t.Fatal("failed to create table with overridden name", err)
+	}
+	if _, err := s.Insert(&Customer{ID: 1, FullName: "Tom"}, &Customer{ID: 2, FullName: "Sam"}); err != nil {
+		t.Fatal("failed to insert with overridden columns", err)
+	}
+	if _, err := s.Where("full_name = ?", "Tom").Update(&Customer{CreatedAt: 100}); err != nil {
+		t.Fatal("failed to update with overridden columns", err)
+	}
+	var customers []Customer
+	if err := s.OrderBy("id").Find(&customers); err != nil || len(customers) != 2 ||
+		customers[0].FullName != "Tom" || customers[0].CreatedAt != 100 || customers[1].FullName != "Sam" {
+		t.Fatal("failed to query overridden columns", err, customers)
+	}
+}
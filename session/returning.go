@@ -0,0 +1,168 @@
+// 用于放置 RETURNING 子句相关的代码
+package session
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"geeorm/clause"
+	"geeorm/dialect"
+	"reflect"
+	"slices"
+)
+
+// Returning 为 INSERT、UPDATE、DELETE 语句添加 RETURNING 子句，返回值是 *Session 可以链式调用
+//
+// 参数:
+// columns: 要返回的列，为空时返回所有列
+//
+// Insert 返回的行按主键填充回传入的记录，只插入一条记录时不需要主键，结构体形式的 Update 返回的行填充回传入的结构体；
+// 需要数据库支持 dialect.Returning（SQLite 3.35.0 起、Postgres）
+//
+// 示例:
+// u := &User{Name: "Tom"}
+// _, err := s.Returning("Age").Insert(u) // u.Age 为数据库中的默认值
+func (s *Session) Returning(columns ...string) *Session {
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	s.returning = columns
+	return s
+}
+
+// ReturningInto 与 Returning 相同，但将返回的行填充到 dest 中，返回值是 *Session 可以链式调用
+//
+// 参数:
+// dest: 要填充的对象指针，支持的类型与 Scan 相同，切片会先被清空
+// columns: 要返回的列，为空时返回所有列
+//
+// 示例:
+// var deleted []User
+// _, err := s.Where("Age > ?", 60).ReturningInto(&deleted).Delete()
+func (s *Session) ReturningInto(dest interface{}, columns ...string) *Session {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
+		s.err = fmt.Errorf("returning destination must be a non-nil pointer, got %T", dest)
+		return s
+	}
+	if isSliceDest(destValue.Elem()) {
+		destValue.Elem().Set(reflect.MakeSlice(destValue.Elem().Type(), 0, 0))
+	}
+	s.returnTo = dest
+	return s.Returning(columns...)
+}
+
+// execWrite 按 stmt 的子句构建顺序构建并执行 INSERT、UPDATE、DELETE 语句，返回受影响的行数
+//
+// 设置了 Returning 时设置 RETURNING 子句，受影响的行数即返回的行数；
+// 返回的行填充到 ReturningInto 指定的对象中，没有指定时填充到 targets 中。
+// 数据库不保证 RETURNING 返回的行的顺序，因此有多个 targets 时按主键将返回的行与 targets 对应，
+// 此时模型必须有主键，并且 targets 的主键都已经设置且互不相同，否则返回错误
+func (s *Session) execWrite(targets []interface{}, stmt clause.Statement) (int64, error) {
+	if s.returning == nil {
+		sql, vars, err := s.clause.BuildStatement(stmt, s.dialect)
+		if err != nil {
+			s.Clear()
+			return 0, err
+		}
+		result, err := s.prepare(sql, vars...).Exec()
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+	if !s.dialect.Supports(dialect.Returning) {
+		s.Clear()
+		return 0, errors.New("RETURNING is not supported by the dialect")
+	}
+	dest := s.returnTo
+	var byKey map[interface{}]reflect.Value
+	var err error
+	if dest == nil && len(targets) > 1 {
+		if byKey, err = s.targetsByKey(targets); err != nil {
+			s.Clear()
+			return 0, err
+		}
+	}
+	s.clause.Set(clause.RETURNING, s.returning)
+	sql, vars, err := s.clause.BuildStatement(stmt, s.dialect)
+	if err != nil {
+		s.Clear()
+		return 0, err
+	}
+	rows, err := s.prepare(sql, vars...).QueryRows()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if dest != nil {
+		n, err := scanInto(rows, columns, reflect.ValueOf(dest).Elem())
+		return int64(n), err
+	}
+	if byKey != nil {
+		typ := reflect.Indirect(reflect.ValueOf(targets[0])).Type()
+		return scanByKey(rows, columns, byKey, typ, s.refTable.PrimaryField.Name)
+	}
+	var n int64
+	for rows.Next() {
+		if n < int64(len(targets)) {
+			if err = scanRow(rows, columns, reflect.Indirect(reflect.ValueOf(targets[n]))); err != nil {
+				return n, err
+			}
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+// targetsByKey 返回 targets 的主键到记录的映射，并确保 RETURNING 子句包含主键列
+func (s *Session) targetsByKey(targets []interface{}) (map[interface{}]reflect.Value, error) {
+	table := s.RefTable()
+	if table.PrimaryField == nil {
+		return nil, fmt.Errorf("RETURNING into %d records of table %s requires a primary key, use ReturningInto instead",
+			len(targets), table.Name)
+	}
+	pk := table.PrimaryField
+	byKey := make(map[interface{}]reflect.Value, len(targets))
+	for _, target := range targets {
+		value := reflect.Indirect(reflect.ValueOf(target))
+		key := value.FieldByName(pk.FieldName)
+		if key.IsZero() {
+			return nil, fmt.Errorf("RETURNING into multiple records requires their primary key %s to be set, use ReturningInto instead", pk.Name)
+		}
+		if _, ok := byKey[key.Interface()]; ok {
+			return nil, fmt.Errorf("duplicate primary key %v in RETURNING records", key.Interface())
+		}
+		byKey[key.Interface()] = value
+	}
+	if !slices.Contains(s.returning, "*") && !slices.Contains(s.returning, pk.Name) {
+		s.returning = append(append([]string{}, s.returning...), pk.Name)
+	}
+	return byKey, nil
+}
+
+// scanByKey 将 rows 中的每一行读取为 typ 类型的结构体，再填充到主键 pk 相同的记录中，返回读取的行数
+func scanByKey(rows *sql.Rows, columns []string, byKey map[interface{}]reflect.Value, typ reflect.Type, pk string) (int64, error) {
+	var n int64
+	for rows.Next() {
+		elem := reflect.New(typ).Elem()
+		if err := scanRow(rows, columns, elem); err != nil {
+			return n, err
+		}
+		n++
+		target, ok := byKey[structField(elem, pk).Interface()]
+		if !ok {
+			return n, fmt.Errorf("RETURNING row with primary key %v does not match any record", structField(elem, pk).Interface())
+		}
+		for _, column := range columns {
+			if field := structField(elem, column); field.IsValid() && field.CanSet() {
+				structField(target, column).Set(field)
+			}
+		}
+	}
+	return n, rows.Err()
+}
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"testing"
+
+	"geeorm/dialect"
+)
+
+type Item struct {
+	ID    int `geeorm:"PRIMARY KEY AUTOINCREMENT"`
+	Name  string
+	Stock int `geeorm:"DEFAULT 10"`
+}
+
+func testReturningInit(t *testing.T) *Session {
+	t.Helper()
+	s := NewSession().Model(&Item{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table", err)
+	}
+	return s
+}
+
+func TestSession_ReturningInsert(t *testing.T) {
+	s := testReturningInit(t)
+	item := &Item{Name: "apple"}
+	affected, err := s.Omit("ID", "Stock").Returning("ID", "Stock").Insert(item)
+	if err != nil || affected != 1 || item.ID != 1 || item.Stock != 10 {
+		t.Fatal("failed to insert with returning", err, affected, *item)
+	}
+	// 多条记录按主键对应返回的行，不依赖返回的行的顺序
+	items := []*Item{{ID: 5, Name: "pear"}, {ID: 3, Name: "plum"}}
+	affected, err = s.Omit("Stock").Returning("Name", "Stock").Insert(items)
+	if err != nil || affected != 2 || items[0].Name != "pear" || items[1].Name != "plum" ||
+		items[0].Stock != 10 || items[1].Stock != 10 {
+		t.Fatal("failed to scan returning rows into records by primary key", err, *items[0], *items[1])
+	}
+	// 主键由数据库生成时无法对应返回的行
+	_, err = s.Omit("ID").Returning("ID").Insert([]*Item{{Name: "fig"}, {Name: "kiwi"}})
+	if count, _ := s.Count(); err == nil || count != 3 {
+		t.Fatal("expect error when records cannot be matched by primary key", err, count)
+	}
+}
+
+func TestSession_ReturningUpdateAndDelete(t *testing.T) {
+	s := testReturningInit(t)
+	_, _ = s.Omit("ID").Insert([]Item{{Name: "apple", Stock: 3}, {Name: "pear", Stock: 5}})
+
+	item := &Item{Stock: 7}
+	affected, err := s.Select("Stock").Where("Name = ?", "pear").Returning().Update(item)
+	if err != nil || affected != 1 || item.ID != 2 || item.Name != "pear" || item.Stock != 7 {
+		t.Fatal("failed to update with returning", err, affected, item)
+	}
+
+	var stocks []int
+	affected, err = s.Model(&Item{}).ReturningInto(&stocks, "Stock").Update("Stock", 0)
+	if err != nil || affected != 2 || len(stocks) != 2 || stocks[0] != 0 {
+		t.Fatal("failed to update with returning into slice", err, affected, stocks)
+	}
+
+	var deleted []Item
+	affected, err = s.Where("Name = ?", "apple").ReturningInto(&deleted).Delete()
+	if err != nil || affected != 1 || len(deleted) != 1 || deleted[0].ID != 1 {
+		t.Fatal("failed to delete with returning", err, affected, deleted)
+	}
+}
+
+func TestSession_ReturningUnsupported(t *testing.T) {
+	s := testReturningInit(t)
+	d, _ := dialect.GetDialect("sqlite3")
+	if d.Supports(dialect.Returning) || !TestDial.Supports(dialect.Returning) {
+		t.Fatal("RETURNING should be supported only after detecting SQLite 3.35.0 or newer")
+	}
+	item := &Item{Name: "apple"}
+	if _, err := New(TestDB, d).Model(&Item{}).Returning("ID").Insert(item); err == nil {
+		t.Fatal("expect error when the dialect does not support RETURNING")
+	}
+	if count, _ := s.Count(); count != 0 {
+		t.Fatal("record should not be inserted without RETURNING support", count)
+	}
+}
@@ -0,0 +1,202 @@
+// 用于放置将查询结果扫描到任意类型的代码
+package session
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"geeorm/schema"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Scan 执行查询并将结果按列名填充到 dest 中
+//
+// 参数:
+// dest: 要填充的对象指针，支持以下类型：
+//   - 结构体或结构体（指针）的切片，列按名称（不区分大小写）映射到字段，没有对应字段的列被忽略
+//   - map[string]interface{} 或它的切片，键为列名
+//   - 标量或标量的切片，此时查询结果只能有一列；time.Time 和 sql.NullString 等实现了 sql.Scanner 的类型都是标量
+//
+// 返回值:
+// error: 如果查询或填充过程中发生错误，返回错误信息；dest 不是切片且没有查到记录时返回 NOT FOUND
+//
+// 如果通过 Raw 设置了 SQL 语句，则执行该语句；否则根据 Model、Table、Select 和各子句构建 SELECT 语句。
+// 结构体不需要是已注册的模型。
+//
+// 示例:
+// var result []struct{ Name string; Total int }
+// err := s.Raw("SELECT Name, count(*) AS Total FROM User GROUP BY Name").Scan(&result)
+// var rows []map[string]interface{}
+// err := s.Model(&User{}).Where("Age > ?", 18).Scan(&rows)
+func (s *Session) Scan(dest interface{}) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
+		s.Clear()
+		return fmt.Errorf("scan destination must be a non-nil pointer, got %T", dest)
+	}
+	rows, err := s.query()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	destValue = destValue.Elem()
+	if isSliceDest(destValue) {
+		destValue.Set(reflect.MakeSlice(destValue.Type(), 0, 0))
+	}
+	n, err := scanInto(rows, columns, destValue)
+	if err != nil {
+		return err
+	}
+	if n == 0 && !isSliceDest(destValue) {
+		return errors.New("NOT FOUND")
+	}
+	return nil
+}
+
+// Pluck 查询单独一列并填充到 dest 中
+//
+// 参数:
+// column: 要查询的列名
+// dest: 要填充的切片指针
+//
+// 返回值:
+// error: 如果查询过程中发生错误，返回错误信息
+//
+// 示例:
+// var names []string
+// err := s.Model(&User{}).Pluck("Name", &names)
+func (s *Session) Pluck(column string, dest interface{}) error {
+	s.selects = []string{column}
+	return s.Scan(dest)
+}
+
+// query 执行通过 Raw 设置的 SQL 语句，没有时通过 buildSelect 构建 SELECT 语句后执行
+func (s *Session) query() (*sql.Rows, error) {
+	if s.sql.Len() == 0 {
+		sql, vars, err := s.buildSelect()
+		if err != nil {
+			s.Clear()
+			return nil, err
+		}
+		s.prepare(sql, vars...)
+	}
+	return s.QueryRows()
+}
+
+var (
+	bytesType   = reflect.TypeOf([]byte(nil))
+	timeType    = reflect.TypeOf(time.Time{})
+	mapType     = reflect.TypeOf(map[string]interface{}(nil))
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// isRowStruct 判断 typ 是否是按列名映射字段的结构体，time.Time 和实现了 sql.Scanner 的 sql.NullString 等类型作为标量处理
+func isRowStruct(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && typ != timeType && !reflect.PointerTo(typ).Implements(scannerType)
+}
+
+// isSliceDest 判断 dest 是否是每一行填充一个元素的切片，[]byte 作为标量处理
+func isSliceDest(dest reflect.Value) bool {
+	return dest.Kind() == reflect.Slice && dest.Type() != bytesType
+}
+
+// scanInto 将 rows 中的行填充到 dest 中，返回读取的行数
+//
+// dest 是切片时每一行追加一个元素，否则只填充第一行
+func scanInto(rows *sql.Rows, columns []string, dest reflect.Value) (int, error) {
+	n := 0
+	if !isSliceDest(dest) {
+		for rows.Next() {
+			if n == 0 {
+				if err := scanRow(rows, columns, dest); err != nil {
+					return n, err
+				}
+			}
+			n++
+		}
+		return n, rows.Err()
+	}
+	elemType := dest.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		if err := scanRow(rows, columns, elem.Elem()); err != nil {
+			return n, err
+		}
+		if !isPtr {
+			elem = elem.Elem()
+		}
+		dest.Set(reflect.Append(dest, elem))
+		n++
+	}
+	return n, rows.Err()
+}
+
+// scanRow 将 rows 的当前行填充到 dest 中
+func scanRow(rows *sql.Rows, columns []string, dest reflect.Value) error {
+	switch {
+	case dest.Type() == mapType:
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		m := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			m[column] = values[i]
+		}
+		dest.Set(reflect.ValueOf(m))
+		return nil
+	case dest.Kind() == reflect.Ptr && isRowStruct(dest.Type().Elem()):
+		elem := reflect.New(dest.Type().Elem())
+		if err := scanRow(rows, columns, elem.Elem()); err != nil {
+			return err
+		}
+		dest.Set(elem)
+		return nil
+	case isRowStruct(dest.Type()):
+		ptrs := make([]interface{}, len(columns))
+		for i, column := range columns {
+			field := structField(dest, column)
+			if field.IsValid() && field.CanSet() {
+				ptrs[i] = field.Addr().Interface()
+			} else {
+				// 没有对应字段的列被忽略
+				ptrs[i] = new(interface{})
+			}
+		}
+		return rows.Scan(ptrs...)
+	default:
+		if len(columns) != 1 {
+			return fmt.Errorf("scan %s expects 1 column, got %d", dest.Type(), len(columns))
+		}
+		return rows.Scan(dest.Addr().Interface())
+	}
+}
+
+// structField 返回结构体 dest 中与列 column 对应的字段
+//
+// 优先匹配标签中 column 选项声明的列名，其次按字段名匹配，都不区分大小写；没有对应字段时返回零值
+func structField(dest reflect.Value, column string) reflect.Value {
+	typ := dest.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if p := typ.Field(i); p.IsExported() && strings.EqualFold(schema.ColumnName(p), column) {
+			return dest.Field(i)
+		}
+	}
+	return dest.FieldByNameFunc(func(name string) bool {
+		return strings.EqualFold(name, column)
+	})
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSession_Scan(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+
+	var stats []struct {
+		Age   int
+		Total int64
+	}
+	err := s.Raw("SELECT Age, count(*) AS total FROM User GROUP BY Age ORDER BY Age").Scan(&stats)
+	if err != nil || len(stats) != 2 || stats[1].Age != 25 || stats[1].Total != 2 {
+		t.Fatal("failed to scan into struct slice", err, stats)
+	}
+
+	var rows []map[string]interface{}
+	err = s.Model(&User{}).Where("Name = ?", "Tom").Scan(&rows)
+	if err != nil || len(rows) != 1 || rows[0]["Age"] != int64(18) {
+		t.Fatal("failed to scan into map slice", err, rows)
+	}
+
+	var count int
+	if err = s.Raw("SELECT count(*) FROM User").Scan(&count); err != nil || count != 3 {
+		t.Fatal("failed to scan into scalar", err, count)
+	}
+
+	var u *User
+	if err = s.Model(&User{}).Where("Name = ?", "Nobody").Scan(&u); err == nil {
+		t.Fatal("expect NOT FOUND error")
+	}
+	if err = s.Model(&User{}).Where("Name = ?", "Sam").Scan(&u); err != nil || u == nil || u.Age != 25 {
+		t.Fatal("failed to scan into struct pointer", err, u)
+	}
+}
+
+func TestSession_Pluck(t *testing.T) {
+	s := testRecordInit(t)
+	var names []string
+	err := s.Model(&User{}).OrderBy("Age").Pluck("Name", &names)
+	if err != nil || len(names) != 2 || names[0] != "Tom" || names[1] != "Sam" {
+		t.Fatal("failed to pluck", err, names)
+	}
+}
+
+func TestSession_PluckScanner(t *testing.T) {
+	s := testRecordInit(t)
+	var names []sql.NullString
+	err := s.Model(&User{}).OrderBy("Age").Pluck("Name", &names)
+	if err != nil || len(names) != 2 || !names[0].Valid || names[0].String != "Tom" || names[1].String != "Sam" {
+		t.Fatal("failed to pluck into sql.NullString", err, names)
+	}
+	var ages []*sql.NullInt64
+	err = s.Raw("SELECT Age FROM User ORDER BY Age").Scan(&ages)
+	if err != nil || len(ages) != 2 || ages[0].Int64 != 18 || ages[1].Int64 != 25 {
+		t.Fatal("failed to scan into *sql.NullInt64", err, ages)
+	}
+}
@@ -12,11 +12,15 @@ import (
 // Model 设置当前会话操作的模型
 //
 // 参数:
-// value: 要操作的模型对象
+// value: 要操作的模型对象，也可以是 *schema.Schema，例如 Schema.JoinTables 中的中间表
 //
 // 返回值:
 // *Session: 返回当前会话实例
 func (s *Session) Model(value interface{}) *Session {
+	if table, ok := value.(*schema.Schema); ok {
+		s.refTable = table
+		return s
+	}
 	if s.refTable == nil || reflect.TypeOf(value) != reflect.TypeOf(s.refTable.Model) {
 		s.refTable = schema.Parse(value, s.dialect)
 	}
@@ -34,18 +38,125 @@ func (s *Session) RefTable() *schema.Schema {
 	return s.refTable
 }
 
-// CreateTable 创建数据库表
+// CreateTable 创建数据库表，以及标签中声明的索引、外键、CHECK 约束和生成列
 //
 // 返回值:
 // error: 如果创建过程中发生错误，返回错误信息
+//
+// 不会创建切片类型的关联字段对应的中间表，中间表由 Engine.AutoMigrate 创建
 func (s *Session) CreateTable() error {
 	table := s.RefTable()
+	statements := []string{createTableSQL(table, table.Name)}
+	for _, index := range table.Indexes {
+		statements = append(statements, s.createIndexSQL(index))
+	}
+	return s.execStatements(statements)
+}
+
+// createTableSQL 返回按 table 的定义创建名为 name 的表的 SQL 语句
+func createTableSQL(table *schema.Schema, name string) string {
 	var columns []string
 	for _, field := range table.Fields {
-		columns = append(columns, fmt.Sprintf("%s %s %s", field.Name, field.Type, field.Tag))
+		columns = append(columns, columnSQL(field))
+	}
+	if len(table.PrimaryKeys) > 0 {
+		columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(table.PrimaryKeys, ", ")))
+	}
+	for _, fk := range table.ForeignKeys {
+		if !fk.Inline {
+			columns = append(columns, foreignKeySQL(fk))
+		}
+	}
+	for _, check := range table.Checks {
+		columns = append(columns, fmt.Sprintf("CONSTRAINT %s CHECK (%s)", check.Name, check.Expression))
 	}
 	desc := strings.Join(columns, ",")
-	_, err := s.Raw(fmt.Sprintf("CREATE TABLE %s (%s);", table.Name, desc)).Exec()
+	return fmt.Sprintf("CREATE TABLE %s (%s);", name, desc)
+}
+
+// columnSQL 返回字段 field 对应的列定义，生成列带有 GENERATED ALWAYS AS 子句
+func columnSQL(field *schema.Field) string {
+	sql := fmt.Sprintf("%s %s %s", field.Name, field.Type, field.Tag)
+	if field.Generated != "" {
+		sql += fmt.Sprintf(" GENERATED ALWAYS AS (%s)", field.Generated)
+		if field.Stored {
+			sql += " STORED"
+		} else {
+			sql += " VIRTUAL"
+		}
+	}
+	return sql
+}
+
+// foreignKeySQL 返回外键 fk 的表约束
+func foreignKeySQL(fk *schema.ForeignKey) string {
+	sql := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s", fk.Column, fk.RefTable)
+	if fk.RefColumn != "" {
+		sql += fmt.Sprintf("(%s)", fk.RefColumn)
+	}
+	if fk.OnDelete != "" {
+		sql += " ON DELETE " + fk.OnDelete
+	}
+	if fk.OnUpdate != "" {
+		sql += " ON UPDATE " + fk.OnUpdate
+	}
+	return sql
+}
+
+// createIndexSQL 返回在当前模型对应的表上创建索引 index 的 SQL 语句
+func (s *Session) createIndexSQL(index *schema.Index) string {
+	columns := make([]string, len(index.Columns))
+	for i, column := range index.Columns {
+		columns[i] = column
+		if s.refTable.GetField(column) != nil {
+			columns[i] = s.dialect.Quote(column)
+		}
+	}
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	sql := fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, s.dialect.Quote(index.Name),
+		s.dialect.Quote(s.refTable.Name), strings.Join(columns, ", "))
+	if index.Where != "" {
+		sql += " WHERE " + index.Where
+	}
+	return sql
+}
+
+// CreateIndex 在当前模型对应的表上创建索引
+//
+// 参数:
+// name: 索引名
+// columns: 索引的列；为空时创建标签中声明的名为 name 的索引，唯一索引、部分索引和表达式索引需要在标签中声明
+//
+// 返回值:
+// error: 如果没有指定列且标签中没有声明该索引，或者创建失败，返回错误信息
+//
+// 示例:
+// err := s.Model(&User{}).CreateIndex("idx_user_name_age", "Name", "Age")
+// err := s.Model(&User{}).CreateIndex("idx_user_email") // 标签中声明的索引
+func (s *Session) CreateIndex(name string, columns ...string) error {
+	table := s.RefTable()
+	index := &schema.Index{Name: name, Columns: columns}
+	if len(columns) == 0 {
+		if index = table.GetIndex(name); index == nil {
+			return fmt.Errorf("index %s is not declared in table %s", name, table.Name)
+		}
+	}
+	_, err := s.Raw(s.createIndexSQL(index)).Exec()
+	return err
+}
+
+// DropIndex 删除索引
+//
+// 参数:
+// name: 索引名
+//
+// 返回值:
+// error: 如果删除过程中发生错误，返回错误信息
+func (s *Session) DropIndex(name string) error {
+	_, err := s.Raw(fmt.Sprintf("DROP INDEX %s", s.dialect.Quote(name))).Exec()
 	return err
 }
 
@@ -64,9 +175,8 @@ func (s *Session) DropTable() error {
 // bool: 如果表存在，返回 true；否则返回 false
 func (s *Session) HasTable() bool {
 	sql, values := s.dialect.TableExistSQL(s.RefTable().Name)
-	row := s.Raw(sql, values...).QueryRow()
 	var tmp string
-	// row.Scan 将数据库中的值扫描到 tmp 中
-	_ = row.Scan(&tmp)
+	// 将数据库中的值扫描到 tmp 中
+	_ = s.prepare(sql, values...).queryValue(&tmp)
 	return tmp == s.RefTable().Name
 }
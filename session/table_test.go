@@ -26,3 +26,86 @@ func TestSession_Model(t *testing.T) {
 		t.Fatal("Failed to change model")
 	}
 }
+
+type Student struct {
+	Name  string `geeorm:"PRIMARY KEY;uniqueIndex:idx_name_age,priority:2"`
+	Age   int    `geeorm:"NOT NULL;uniqueIndex:idx_name_age,priority:1;index:,where:Age > 0"`
+	Email string `geeorm:"index:idx_email,expression:lower(Email)"`
+}
+
+func TestSession_CreateIndex(t *testing.T) {
+	s := NewSession().Model(&Student{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table", err)
+	}
+	indexes, _ := s.Indexes()
+	sqls := make(map[string]string)
+	for _, index := range indexes {
+		sqls[index.Name] = index.SQL
+	}
+	if sqls["idx_name_age"] != `CREATE UNIQUE INDEX "idx_name_age" ON "Student" ("Age", "Name")` ||
+		sqls["idx_Student_Age"] != `CREATE INDEX "idx_Student_Age" ON "Student" ("Age") WHERE Age > 0` ||
+		sqls["idx_email"] != `CREATE INDEX "idx_email" ON "Student" (lower(Email))` {
+		t.Fatal("failed to create declared indexes", sqls)
+	}
+	_, _ = s.Insert(&Student{Name: "Tom", Age: 18})
+	if _, err := s.Insert(&Student{Name: "Tom", Age: 18}); err == nil {
+		t.Fatal("failed to create unique index")
+	}
+
+	if err := s.DropIndex("idx_email"); err != nil {
+		t.Fatal("failed to drop index", err)
+	}
+	if err := s.CreateIndex("idx_email"); err != nil {
+		t.Fatal("failed to create declared index", err)
+	}
+	if err := s.CreateIndex("idx_student_email", "Email"); err != nil {
+		t.Fatal("failed to create index", err)
+	}
+	if err := s.CreateIndex("idx_unknown"); err == nil {
+		t.Fatal("expect error for undeclared index")
+	}
+	if diff, err := s.DiffTable(); err != nil || !diff.Empty() {
+		t.Fatal("undeclared indexes should be kept", diff, err)
+	}
+}
+
+type Product struct {
+	Name     string  `geeorm:"PRIMARY KEY"`
+	Price    float64 `geeorm:"check:Price >= 0"`
+	Quantity int     `geeorm:"check:chk_quantity,Quantity BETWEEN 0 AND 1000"`
+	Total    float64 `geeorm:"generated:Price * Quantity,stored"`
+	Label    string  `geeorm:"generated:Name || ' x' || Quantity"`
+}
+
+func TestSession_CheckAndGenerated(t *testing.T) {
+	s := NewSession().Model(&Product{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table", err)
+	}
+	if _, err := s.Insert(&Product{Name: "Pen", Price: 1.5, Quantity: 4, Total: 100, Label: "ignored"}); err != nil {
+		t.Fatal("failed to insert record with generated columns", err)
+	}
+	if _, err := s.Insert(&Product{Name: "Ink", Price: -1}); err == nil {
+		t.Fatal("expect error for violated check constraint")
+	}
+	if _, err := s.Where("Name = ?", "Pen").Update(&Product{Quantity: 2, Total: 100}); err != nil {
+		t.Fatal("failed to update record with generated columns", err)
+	}
+	p := &Product{}
+	if err := s.First(p); err != nil || p.Total != 3 || p.Label != "Pen x2" {
+		t.Fatal("failed to compute generated columns", p, err)
+	}
+	if _, err := s.Update("Total", 1); err == nil {
+		t.Fatal("expect error when only generated columns are updated")
+	}
+	m := map[string]interface{}{"Quantity": 3, "Total": 100}
+	if _, err := s.Where("Name = ?", "Pen").Update(m); err != nil || len(m) != 2 || m["Total"] != 100 {
+		t.Fatal("Update should not modify the caller's map", m, err)
+	}
+	if diff, err := s.DiffTable(); err != nil || !diff.Empty() {
+		t.Fatal("table should match the model", diff, err)
+	}
+}
@@ -50,3 +50,35 @@ func (s *Session) Rollback() (err error) {
 	}
 	return
 }
+
+// runInTx 在事务中执行 f
+//
+// 如果当前会话已经处于事务中，直接在当前会话中执行 f；
+// 否则创建一个新的事务会话，f 返回错误或发生 panic 时回滚，否则提交。
+//
+// 参数:
+//   - f: 要在事务中执行的函数，参数为事务所在的会话。
+//
+// 返回值:
+//   - err: f 返回的错误，或事务开始、提交失败的错误。
+func (s *Session) runInTx(f func(*Session) error) (err error) {
+	if s.tx != nil {
+		return f(s)
+	}
+	tx := New(s.db, s.dialect)
+	tx.refTable = s.refTable
+	if err = tx.Begin(); err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	return f(tx)
+}